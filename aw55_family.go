@@ -0,0 +1,64 @@
+package symbol
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// RegisterAW55Family adds or replaces the definition for a calibration family,
+// in the same schema as the embedded aw55_<family>.json files. A family mined
+// in-house then loads without rebuilding the library.
+func RegisterAW55Family(name string, definition []byte) error {
+	var defs aw55Defs
+	if err := json.Unmarshal(definition, &defs); err != nil {
+		return fmt.Errorf("AW55 family %s: %w", name, err)
+	}
+	if defs.Family != "" && defs.Family != name {
+		return fmt.Errorf("AW55 family %s: definition is for %s", name, defs.Family)
+	}
+	if len(defs.Maps) == 0 && len(defs.CurveDirs) == 0 {
+		return fmt.Errorf("AW55 family %s: definition has no maps", name)
+	}
+	aw55FamiliesMu.Lock()
+	defer aw55FamiliesMu.Unlock()
+	aw55Families[name] = definition
+	return nil
+}
+
+// LoadAW55Families registers every aw55_<family>.json in dir and returns the
+// families it found.
+func LoadAW55Families(dir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "aw55_*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, file := range files {
+		name := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(file), "aw55_"), ".json")
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return names, err
+		}
+		if err := RegisterAW55Family(name, data); err != nil {
+			return names, err
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// AW55Families lists the calibration families a dump can be loaded with.
+func AW55Families() []string {
+	aw55FamiliesMu.RLock()
+	defer aw55FamiliesMu.RUnlock()
+	names := make([]string, 0, len(aw55Families))
+	for name := range aw55Families {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
@@ -10,31 +10,115 @@ package symbol
 //	lookup  (x, short *axis, short *data, byte n)              // 1-D curve
 //	lookup2d(x, xaxis, ncols, y, yaxis, nrows, data)           // 2-D map
 //
-// so the maps were mined by resolving those call sites statically (see
-// GTISSPS/_re/tcm_y867 for the tooling) and are embedded here per calibration
-// family. Names are not recoverable, so the maps come out as Symbol-0, 1, 2 …
-// to be identified and renamed as they are decoded.
+// so the maps are mined by resolving those call sites statically (MineAW55)
+// and are embedded here per calibration family. Names are not recoverable, so the maps come out as Symbol-0, 1, 2 …
+// to be identified and renamed as they are decoded; an AW55Overlay carries
+// those identifications from one dump of the family to the next.
 
 import (
+	"bytes"
 	_ "embed"
 	"encoding/binary"
 	"encoding/json"
 	"fmt"
+	"os"
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 type AW55File struct {
 	data []byte
+
+	printFunc func(string)
+	eventFunc func(LoadEvent)
+
+	checksumSlot int             // file offset of the calibration checksum, -1 if not found
+	curves       []aw55CurveSyms // record-format curves, written back interleaved
+	shifts       []aw55ShiftRow  // the shift schedule, one row per mode
+	upper        AW55UpperHalf   // what the upper half of a 1 MB dump held on load
+
+	// layout is what GetFileInfo reports, kept as symbols so a rename or a new
+	// description only has to rebuild the AxisInformation from it.
+	layout      []aw55Layout
+	axes        AxisInformation // built from layout
+	overlay     *AW55Overlay
+	defaults    map[*Symbol]aw55Default // as generated, before any overlay
+	description map[*Symbol]string
+	definition  *Definition
+
 	*Collection
 }
 
+type AW55FileOpt func(*AW55File) error
+
+// WithAW55EventFunc receives the typed counterpart of the printFunc messages.
+func WithAW55EventFunc(f func(LoadEvent)) AW55FileOpt {
+	return func(aw55 *AW55File) error {
+		aw55.eventFunc = f
+		return nil
+	}
+}
+
+func (f *AW55File) event(e LoadEvent) {
+	if f.eventFunc == nil {
+		return
+	}
+	e.ECU = ECU_AW55
+	f.eventFunc(e)
+}
+
+// aw55CurveSyms ties the two symbols a record-format curve was split into back
+// to the record, so Byte can interleave them again.
+type aw55CurveSyms struct {
+	addr uint32
+	x, y *Symbol
+}
+
+// Byte writes the symbols into a copy of the image, which only replaces the
+// file's own once everything was written. Without a checksum slot it fails
+// with ErrChecksumAreaNotFound rather than give out an image whose checksum
+// no longer matches.
 func (f *AW55File) Byte() ([]byte, error) {
-	return f.data, nil
+	if f.checksumSlot < 0 {
+		return nil, f.noChecksumSlot()
+	}
+	out := bytes.Clone(f.data)
+	curve := make(map[*Symbol]bool, 2*len(f.curves))
+	for _, c := range f.curves {
+		curve[c.x], curve[c.y] = true, true
+	}
+	for _, sym := range f.Symbols() {
+		if sym.Address == 0 || curve[sym] {
+			continue
+		}
+		if int(sym.Address)+len(sym.data) > len(out) {
+			return nil, &SymbolError{ECU: ECU_AW55, Name: sym.Name, Address: sym.Address, Err: ErrAddressOutOfRange}
+		}
+		copy(out[sym.Address:], sym.data)
+	}
+	for _, c := range f.curves {
+		if err := aw55PutCurve(out, c); err != nil {
+			return nil, err
+		}
+	}
+	aw55PutChecksum(out, f.checksumSlot)
+	if f.upper == UpperMirror {
+		copy(out[aw55Length:], out[:aw55Length])
+	}
+	f.data = out
+	return out, nil
 }
 
 func (f *AW55File) Save(filename string) error {
+	data, err := f.Byte()
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filename, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s : %w", filename, err)
+	}
 	return nil
 }
 
@@ -42,7 +126,7 @@ const (
 	aw55CalBase = 0x70000 // calibration start in flash
 	aw55CalEnd  = 0x80000
 	aw55Magic   = 0x12345678 // first longword of the calibration
-	aw55Length  = 0x80000    // the checksummed image; 1 MB dumps are accepted too
+	aw55Length  = 0x80000    // the used half; 1 MB dumps are accepted too (see Regions)
 )
 
 //go:embed aw55_Y867.json
@@ -51,10 +135,15 @@ var aw55Y867 []byte
 //go:embed aw55_Y802.json
 var aw55Y802 []byte
 
-var aw55Families = map[string][]byte{
-	"Y867": aw55Y867,
-	"Y802": aw55Y802,
-}
+// aw55Families holds the definitions per calibration family: the embedded
+// ones, plus whatever was added with RegisterAW55Family or LoadAW55Families.
+var (
+	aw55Families = map[string][]byte{
+		"Y867": aw55Y867,
+		"Y802": aw55Y802,
+	}
+	aw55FamiliesMu sync.RWMutex
+)
 
 // aw55Definition is one mined calibration object. A curve has rows == 1.
 type aw55Definition struct {
@@ -86,6 +175,9 @@ type aw55Defs struct {
 	// address the second calibration format (see aw55Curve). The first one is
 	// the shift schedule.
 	CurveDirs []uint32 `json:"curveDirs"`
+	// Checksum pins the calibration checksum slot; when absent it is searched
+	// for (see aw55ChecksumSlot).
+	Checksum uint32 `json:"checksum,omitempty"`
 }
 
 // familyRe matches the source-control keywords the calibration carries, e.g.
@@ -114,32 +206,55 @@ func AW55Family(data []byte) string {
 	return ""
 }
 
-func NewAW55File(data []byte, printFunc func(string)) (FirmwareFile, error) {
+func NewAW55File(data []byte, printFunc func(string), opts ...AW55FileOpt) (FirmwareFile, error) {
 	if err := IsAW55File(data); err != nil {
 		return nil, err
 	}
+	aw55 := &AW55File{
+		data:      data,
+		printFunc: printFunc,
+		eventFunc: noEvent,
+		upper:     aw55UpperHalf(data),
+	}
+	for _, opt := range opts {
+		if err := opt(aw55); err != nil {
+			return nil, err
+		}
+	}
+
+	aw55.event(LoadEvent{Kind: EventStageStarted, Stage: StageSymbols})
 	family := AW55Family(data)
+	aw55FamiliesMu.RLock()
 	raw, ok := aw55Families[family]
-	if !ok {
-		return nil, fmt.Errorf("no AW55 definition for calibration family %q", family)
+	aw55FamiliesMu.RUnlock()
+	if !ok && aw55.definition == nil {
+		return nil, fmt.Errorf("no AW55 definition for calibration family %q: %w", family, ErrUnknownFamily)
 	}
 	var defs aw55Defs
-	if err := json.Unmarshal(raw, &defs); err != nil {
-		return nil, err
+	if ok {
+		if err := json.Unmarshal(raw, &defs); err != nil {
+			return nil, err
+		}
+		if printFunc != nil {
+			printFunc(fmt.Sprintf("AW55-50 TCM, calibration family %s, %d maps", family, len(defs.Maps)))
+		}
+		aw55.event(LoadEvent{Kind: EventNameSource, Source: NamesDefinition, Message: family})
 	}
-	if printFunc != nil {
-		printFunc(fmt.Sprintf("AW55-50 TCM, calibration family %s, %d maps", family, len(defs.Maps)))
+	if aw55.upper == UpperData {
+		if printFunc != nil {
+			printFunc("Upper 512 KB holds data that is neither blank nor a mirror; it is kept but not interpreted")
+		}
+		aw55.event(LoadEvent{Kind: EventWarning, Message: "upper half holds uninterpreted data"})
 	}
 
 	// One symbol per map, plus one per axis. The axes are shared between maps,
 	// so they are named by address and only created once.
 	var symbols []*Symbol
-	axes := make(map[uint32]string)
-	axisInfo := make(AxisInformation, len(defs.Maps))
+	axes := make(map[uint32]*Symbol)
 
-	axisSymbol := func(addr uint32, points int) string {
-		if name, ok := axes[addr]; ok {
-			return name
+	axisSymbol := func(addr uint32, points int) *Symbol {
+		if sym, ok := axes[addr]; ok {
+			return sym
 		}
 		info := defs.Axes[strconv.FormatUint(uint64(addr), 10)]
 		// Name it after what drives it where that is known: two axes with the
@@ -154,24 +269,24 @@ func NewAW55File(data []byte, printFunc func(string)) (FirmwareFile, error) {
 		case info.Src != "":
 			name = fmt.Sprintf("Axis@%s-%X", strings.TrimPrefix(info.Src, "0x"), addr)
 		}
-		axes[addr] = name
 		sym := aw55Symbol(data, name, len(symbols), addr, points)
 		sym.Unit = info.Unit
+		axes[addr] = sym
 		symbols = append(symbols, sym)
-		return name
+		return sym
 	}
 
 	for _, m := range defs.Maps {
-		name := fmt.Sprintf("Symbol-%d", m.N)
-		axis := Axis{Z: name}
+		var l aw55Layout
 		if m.Cols > 0 {
-			axis.X = axisSymbol(m.X, m.Cols)
+			l.x = axisSymbol(m.X, m.Cols)
 		}
 		if m.Rows > 1 && m.Y != 0 {
-			axis.Y = axisSymbol(m.Y, m.Rows)
+			l.y = axisSymbol(m.Y, m.Rows)
 		}
-		axisInfo[name] = axis
-		symbols = append(symbols, aw55Symbol(data, name, m.N, m.Data, m.Rows*m.Cols))
+		l.z = aw55Symbol(data, fmt.Sprintf("Symbol-%d", m.N), m.N, m.Data, m.Rows*m.Cols)
+		aw55.layout = append(aw55.layout, l)
+		symbols = append(symbols, l.z)
 	}
 
 	for d, base := range defs.CurveDirs {
@@ -182,6 +297,7 @@ func NewAW55File(data []byte, printFunc func(string)) (FirmwareFile, error) {
 		for i := 0; i < len(curves); i += 10 {
 			row := curves[i:min(i+10, len(curves))]
 			shift := d == 0 && aw55IsShiftRow(row)
+			var mode aw55ShiftRow
 			for k, c := range row {
 				name := fmt.Sprintf("Curve-%X", c.addr)
 				if shift {
@@ -201,21 +317,81 @@ func NewAW55File(data []byte, printFunc func(string)) (FirmwareFile, error) {
 					ys.Unit = "rpm" // the threshold is output-shaft speed
 				}
 				symbols = append(symbols, xs, ys)
-				axisInfo[name] = Axis{X: xName, Z: name}
+				aw55.curves = append(aw55.curves, aw55CurveSyms{addr: c.addr, x: xs, y: ys})
+				aw55.layout = append(aw55.layout, aw55Layout{x: xs, z: ys})
+				mode.curves[k] = aw55.curves[len(aw55.curves)-1]
+			}
+			if shift {
+				mode.mode = i / 10
+				aw55.shifts = append(aw55.shifts, mode)
 			}
 		}
 	}
 
-	axisTranslator[ECU_AW55] = axisInfo
+	aw55.defaults = make(map[*Symbol]aw55Default, len(symbols))
+	aw55.description = make(map[*Symbol]string)
+	for _, sym := range symbols {
+		aw55.defaults[sym] = aw55Default{name: sym.Name, correctionfactor: sym.Correctionfactor, unit: sym.Unit}
+	}
+	generated := aw55.layoutAxes()
+	aw55AxesMu.Lock()
+	aw55Axes = generated
+	aw55AxesMu.Unlock()
+	if aw55.overlay != nil {
+		if err := aw55.applyOverlay(family, symbols); err != nil {
+			return nil, err
+		}
+	}
+	aw55.Collection = NewCollection(symbols...)
+	if aw55.definition != nil {
+		if err := aw55.addDefinition(); err != nil {
+			return nil, err
+		}
+	}
+	aw55.axes = aw55.layoutAxes()
 
-	aw55 := &AW55File{
-		data:       data,
-		Collection: NewCollection(symbols...),
+	aw55.event(LoadEvent{Kind: EventSymbolCount, Count: aw55.Count()})
+	aw55.event(LoadEvent{Kind: EventStageFinished, Stage: StageSymbols})
+
+	aw55.checksumSlot = aw55ChecksumSlot(data, defs.Checksum)
+	if aw55.checksumSlot < 0 {
+		aw55.event(LoadEvent{Kind: EventWarning, Message: "calibration checksum not found, the file cannot be saved"})
+	} else {
+		calculated := aw55CalculateChecksum(data, aw55.checksumSlot)
+		stored := binary.BigEndian.Uint32(data[aw55.checksumSlot:])
+		aw55.event(LoadEvent{Kind: EventChecksum, Area: "CAL", Valid: calculated == stored, Expected: be32(calculated), Actual: be32(stored)})
 	}
 
 	return aw55, nil
 }
 
+// addDefinition loads the symbols only the definition has, laid out the
+// way its tables link them.
+func (f *AW55File) addDefinition() error {
+	symbols, alias, err := f.definition.extra(f.Collection, f.data)
+	if err != nil {
+		return err
+	}
+	f.Add(symbols...)
+	for name, native := range alias {
+		f.event(LoadEvent{Kind: EventWarning, Message: fmt.Sprintf("definition symbol %s overlaps %s, using %s", name, native, native)})
+	}
+	for _, sym := range symbols {
+		f.defaults[sym] = aw55Default{name: sym.Name, correctionfactor: sym.Correctionfactor, unit: sym.Unit}
+		ax, ok := f.definition.Axes[sym.Name]
+		if !ok {
+			continue
+		}
+		l := aw55Layout{x: f.GetByName(aliased(alias, ax.X)), y: f.GetByName(aliased(alias, ax.Y)), z: sym}
+		f.layout = append(f.layout, l)
+		if ax.ZDescription != "" {
+			f.description[sym] = ax.ZDescription
+		}
+	}
+	f.event(LoadEvent{Kind: EventNameSource, Source: NamesDefinition, Message: f.definition.Title})
+	return nil
+}
+
 // The shift schedule and several other curve families are not reachable the way
 // the maps above are: the code loads them through pointer directories in the
 // application image rather than as PC-relative literals, so a scan of the
@@ -266,6 +442,28 @@ func aw55Directory(data []byte, base uint32) []uint32 {
 	return ptr
 }
 
+// aw55PutCurve interleaves a curve's x and y symbols back into its record. The
+// record length is fixed by the terminator in the image, so an edit that would
+// move it is refused: the x stream cannot carry 0xFF, which would end the curve
+// early, and the symbols must still hold exactly one value per point.
+func aw55PutCurve(data []byte, c aw55CurveSyms) error {
+	rec := aw55Curve(data, c.addr)
+	n := len(rec.x) / 2
+	if len(c.x.data) != 2*n || len(c.y.data) != 2*n {
+		return &SymbolError{ECU: ECU_AW55, Name: c.y.Name, Address: c.addr, Expected: 2 * n, Actual: len(c.y.data), Err: ErrDataLength}
+	}
+	for k := 0; k < n; k++ {
+		x := binary.BigEndian.Uint16(c.x.data[2*k:])
+		if x >= aw55CurveEnd {
+			return &SymbolError{ECU: ECU_AW55, Name: c.x.Name, Address: c.addr, Err: fmt.Errorf("point %d: load index %d would end the curve: %w", k, x, ErrDataLength)}
+		}
+		a := int(c.addr) + 4*k
+		data[a] = byte(x)
+		copy(data[a+2:a+4], c.y.data[2*k:2*k+2])
+	}
+	return nil
+}
+
 // aw55IsShiftRow reports whether ten consecutive curves are an
 // upshift/downshift block: all the same length, and A[k] >= B[k] at every
 // breakpoint. That hysteresis invariant is the evidence the identification
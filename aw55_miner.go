@@ -0,0 +1,385 @@
+package symbol
+
+// The AW55 map definitions are mined from the application code rather than
+// read out of a table (see the top of aw55_file.go). MineAW55 does that
+// in-module: a linear sweep over the code keeps track of which registers hold
+// known constants, and at every call into the interpolation library reads the
+// arguments off the registers and the stack:
+//
+//	lookup  (r4 x, r5 axis, r6 data, r7 n)
+//	lookup2d(r4 x, r5 xaxis, r6 ncols, r7 y, @(0,r15) yaxis, @(4,r15) nrows, @(8,r15) data)
+//
+// The x and y inputs are not constants, but when they were loaded GBR-relative
+// the displacement names the RAM variable that drives the axis, which is the
+// "src" of the definition. A sweep does not follow control flow, so state is
+// dropped at every return and unconditional branch, and anything an
+// instruction might overwrite is forgotten; what survives to a call is what the
+// compiler put there just before it, which is all the library calls need.
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/roffe/ecusymbol/sh2"
+)
+
+// AW55MineOpts pins the interpolation routines. Zero addresses are found by
+// looking for the call target whose arguments most often look like a curve
+// (or a map) in the calibration.
+type AW55MineOpts struct {
+	Lookup   uint32
+	Lookup2D uint32
+}
+
+const (
+	aw55MinPoints     = 2
+	aw55MaxAxisPoints = 64
+	aw55MinDirLen     = 10 // a shorter run of calibration pointers is not a directory
+)
+
+type sh2Val struct {
+	known bool
+	gbr   bool // v is a GBR offset, not a constant
+	v     uint32
+}
+
+type aw55CallSite struct {
+	pc     uint32
+	target uint32
+	reg    [4]sh2Val // r4-r7
+	stack  [3]sh2Val // first three stack arguments
+}
+
+// MineAW55 derives the definition of the dump's calibration family from its
+// code and returns it as JSON in the schema RegisterAW55Family takes. Axis
+// labels and units cannot be recovered statically and are left empty.
+func MineAW55(data []byte, opts AW55MineOpts) ([]byte, error) {
+	if err := IsAW55File(data); err != nil {
+		return nil, err
+	}
+	sites := aw55CallSites(data)
+
+	if opts.Lookup == 0 {
+		opts.Lookup = aw55BestTarget(sites, func(c aw55CallSite) bool { _, ok := aw55Curve1D(data, c); return ok })
+	}
+	if opts.Lookup2D == 0 {
+		opts.Lookup2D = aw55BestTarget(sites, func(c aw55CallSite) bool { _, ok := aw55Map2D(data, c); return ok })
+	}
+	if opts.Lookup == 0 && opts.Lookup2D == 0 {
+		return nil, fmt.Errorf("no interpolation routine found in %d call sites", len(sites))
+	}
+
+	defs := aw55Defs{
+		Family: AW55Family(data),
+		Axes:   make(map[string]aw55Axis),
+	}
+	seen := make(map[[3]uint32]bool)
+	addAxis := func(addr uint32, points int, src sh2Val) {
+		key := strconv.FormatUint(uint64(addr), 10)
+		ax, ok := defs.Axes[key]
+		if !ok {
+			ax.Points = points
+		}
+		if ax.Src == "" && src.gbr {
+			ax.Src = fmt.Sprintf("0x%X", src.v)
+		}
+		defs.Axes[key] = ax
+	}
+	for _, c := range sites {
+		var m aw55Definition
+		var ok bool
+		switch {
+		case c.target == 0:
+		case c.target == opts.Lookup:
+			m, ok = aw55Curve1D(data, c)
+		case c.target == opts.Lookup2D:
+			m, ok = aw55Map2D(data, c)
+		}
+		if !ok || seen[[3]uint32{m.Data, m.X, m.Y}] {
+			continue
+		}
+		seen[[3]uint32{m.Data, m.X, m.Y}] = true
+		addAxis(m.X, m.Cols, c.reg[0])
+		if m.Y != 0 {
+			addAxis(m.Y, m.Rows, c.reg[3])
+		}
+		defs.Maps = append(defs.Maps, m)
+	}
+	slices.SortFunc(defs.Maps, func(a, b aw55Definition) int { return int(a.Data) - int(b.Data) })
+	for i := range defs.Maps {
+		defs.Maps[i].N = i
+	}
+	defs.CurveDirs = aw55FindCurveDirs(data)
+
+	return json.MarshalIndent(defs, "", " ")
+}
+
+// aw55CallSites sweeps the application image and records every call with a
+// known target.
+func aw55CallSites(data []byte) []aw55CallSite {
+	var (
+		reg   [16]sh2Val
+		stack []sh2Val // stack[len-1] is @(0,r15)
+		sites []aw55CallSite
+	)
+	reset := func() {
+		reg = [16]sh2Val{}
+		stack = stack[:0]
+	}
+	lit := func(addr uint32, size int) sh2Val {
+		if int(addr)+size > len(data) {
+			return sh2Val{}
+		}
+		if size == 2 {
+			return sh2Val{known: true, v: uint32(int32(int16(binary.BigEndian.Uint16(data[addr:]))))}
+		}
+		return sh2Val{known: true, v: binary.BigEndian.Uint32(data[addr:])}
+	}
+	step := func(i sh2.Inst) {
+		switch i.Op {
+		case sh2.MovImm:
+			reg[i.Rn] = sh2Val{known: true, v: uint32(i.Imm)}
+		case sh2.MovLPC, sh2.MovWPC:
+			reg[i.Rn] = lit(i.Addr, i.Size)
+		case sh2.Mova:
+			reg[0] = sh2Val{known: true, v: i.Addr}
+		case sh2.Mov:
+			reg[i.Rn] = reg[i.Rm]
+		case sh2.ExtuB, sh2.ExtuW, sh2.ExtsB, sh2.ExtsW:
+			v := reg[i.Rm]
+			if v.known && !v.gbr {
+				switch i.Op {
+				case sh2.ExtuB:
+					v.v = uint32(uint8(v.v))
+				case sh2.ExtuW:
+					v.v = uint32(uint16(v.v))
+				case sh2.ExtsB:
+					v.v = uint32(int32(int8(v.v)))
+				case sh2.ExtsW:
+					v.v = uint32(int32(int16(v.v)))
+				}
+			}
+			reg[i.Rn] = v
+		case sh2.AddImm:
+			if i.Rn == 15 {
+				// A positive add releases stack slots, a negative one
+				// allocates slots stored into later.
+				if i.Imm > 0 {
+					stack = stack[:max(0, len(stack)-int(i.Imm)/4)]
+				} else {
+					for k := 0; k < int(-i.Imm)/4; k++ {
+						stack = append(stack, sh2Val{})
+					}
+				}
+				return
+			}
+			if reg[i.Rn].known && !reg[i.Rn].gbr {
+				reg[i.Rn].v += uint32(i.Imm)
+			} else {
+				reg[i.Rn] = sh2Val{}
+			}
+		case sh2.MovGBR:
+			reg[0] = sh2Val{known: true, gbr: true, v: uint32(i.Imm)}
+		case sh2.Push:
+			if i.Rn == 15 {
+				stack = append(stack, reg[i.Rm])
+				return
+			}
+			reg[i.Rn] = sh2Val{}
+		case sh2.Pop:
+			v := sh2Val{}
+			switch {
+			case i.Rm == 15 && i.Size == 4 && len(stack) > 0:
+				v = stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+			case i.Rm == 15:
+				// A short pop leaves the slots misaligned.
+				stack = stack[:0]
+			case reg[i.Rm].known && !reg[i.Rm].gbr:
+				reg[i.Rm].v += uint32(i.Size)
+			default:
+				reg[i.Rm] = sh2Val{}
+			}
+			reg[i.Rn] = v
+		case sh2.StoreDisp:
+			if i.Base == 15 {
+				if k := len(stack) - 1 - int(i.Imm)/4; k >= 0 {
+					stack[k] = reg[i.Rm]
+				}
+			}
+		default:
+			if i.Rn >= 0 {
+				reg[i.Rn] = sh2Val{}
+			}
+		}
+	}
+
+	for pc := uint32(0); pc+4 <= aw55CalBase; pc += 2 {
+		i := sh2.Decode(pc, binary.BigEndian.Uint16(data[pc:]))
+		if !i.HasDelaySlot() {
+			step(i)
+			continue
+		}
+		// The delay slot runs before the branch takes effect.
+		target := reg[i.Rm]
+		if i.Op == sh2.Bsr {
+			target = sh2Val{known: true, v: i.Addr}
+		}
+		pc += 2
+		step(sh2.Decode(pc, binary.BigEndian.Uint16(data[pc:])))
+
+		switch i.Op {
+		case sh2.Jsr, sh2.Bsr:
+			if target.known && !target.gbr {
+				c := aw55CallSite{pc: pc - 2, target: target.v}
+				copy(c.reg[:], reg[4:8])
+				for k := range c.stack {
+					if j := len(stack) - 1 - k; j >= 0 {
+						c.stack[k] = stack[j]
+					}
+				}
+				sites = append(sites, c)
+			}
+			// The callee may clobber r0-r7; the arguments are popped by
+			// the caller, but whatever it pushed is no longer ours to read.
+			for r := 0; r < 8; r++ {
+				reg[r] = sh2Val{}
+			}
+			stack = stack[:0]
+		case sh2.Bts, sh2.Bfs:
+			// Not taken, execution carries on here with the slot done.
+		default:
+			reset()
+		}
+	}
+	return sites
+}
+
+// aw55BestTarget is the call target for which ok holds most often.
+func aw55BestTarget(sites []aw55CallSite, ok func(aw55CallSite) bool) uint32 {
+	hits := make(map[uint32]int)
+	var best uint32
+	for _, c := range sites {
+		if !ok(c) {
+			continue
+		}
+		hits[c.target]++
+		if hits[c.target] > hits[best] || hits[c.target] == hits[best] && c.target < best {
+			best = c.target
+		}
+	}
+	return best
+}
+
+func aw55Curve1D(data []byte, c aw55CallSite) (aw55Definition, bool) {
+	axis, tab, n := c.reg[1], c.reg[2], c.reg[3]
+	if !aw55IsConst(n) || !aw55Points(data, axis, int(n.v)) || !aw55InCal(tab, int(n.v)) {
+		return aw55Definition{}, false
+	}
+	return aw55Definition{Kind: "curve", Data: tab.v, Rows: 1, Cols: int(n.v), X: axis.v}, true
+}
+
+func aw55Map2D(data []byte, c aw55CallSite) (aw55Definition, bool) {
+	xaxis, ncols, yaxis, nrows, tab := c.reg[1], c.reg[2], c.stack[0], c.stack[1], c.stack[2]
+	if !aw55IsConst(ncols) || !aw55IsConst(nrows) {
+		return aw55Definition{}, false
+	}
+	cols, rows := int(ncols.v), int(nrows.v)
+	if !aw55Points(data, xaxis, cols) || !aw55Points(data, yaxis, rows) || !aw55InCal(tab, rows*cols) {
+		return aw55Definition{}, false
+	}
+	return aw55Definition{Kind: "map", Data: tab.v, Rows: rows, Cols: cols, X: xaxis.v, Y: yaxis.v}, true
+}
+
+func aw55IsConst(v sh2Val) bool {
+	return v.known && !v.gbr && v.v >= aw55MinPoints && v.v <= aw55MaxAxisPoints
+}
+
+// aw55InCal reports whether count 16-bit values at v lie in the calibration.
+func aw55InCal(v sh2Val, count int) bool {
+	return v.known && !v.gbr && v.v >= aw55CalBase && v.v%2 == 0 && int(v.v)+2*count <= aw55CalEnd
+}
+
+// aw55Points reports whether v addresses a monotonic axis of count points.
+// Axes run either way, but never back and forth.
+func aw55Points(data []byte, v sh2Val, count int) bool {
+	if !aw55InCal(v, count) {
+		return false
+	}
+	up, down := true, true
+	prev := int16(binary.BigEndian.Uint16(data[v.v:]))
+	for k := 1; k < count; k++ {
+		cur := int16(binary.BigEndian.Uint16(data[int(v.v)+2*k:]))
+		up = up && cur >= prev
+		down = down && cur <= prev
+		prev = cur
+	}
+	return up || down
+}
+
+// aw55FindCurveDirs looks for the pointer directories of the record-format
+// curves: long aligned runs of pointers into the calibration that each address
+// a well-formed record. The one whose first row holds shift points goes first,
+// which is where NewAW55File expects the shift schedule.
+func aw55FindCurveDirs(data []byte) []uint32 {
+	var dirs []uint32
+	for a := 0; a+4 <= aw55CalBase; {
+		ptr := aw55Directory(data, uint32(a))
+		// A run of pointers can start with unrelated ones; the directory is
+		// the valid tail that the terminator ends.
+		n := 0
+		for n < len(ptr) && aw55IsCurveRecord(data, ptr[len(ptr)-1-n]) {
+			n++
+		}
+		if n >= aw55MinDirLen {
+			dirs = append(dirs, uint32(a+4*(len(ptr)-n)))
+		}
+		a += 4 * max(1, len(ptr))
+	}
+	slices.SortStableFunc(dirs, func(a, b uint32) int {
+		return aw55ShiftFirst(data, a) - aw55ShiftFirst(data, b)
+	})
+	return dirs
+}
+
+func aw55ShiftFirst(data []byte, dir uint32) int {
+	ptr := aw55Directory(data, dir)
+	if len(ptr) < 10 {
+		return 1
+	}
+	row := make([]aw55CurveRec, 10)
+	for k := range row {
+		row[k] = aw55Curve(data, ptr[k])
+	}
+	if aw55IsShiftRow(row) {
+		return 0
+	}
+	return 1
+}
+
+// aw55IsCurveRecord reports whether addr holds a terminated record with at
+// least two points whose load index never decreases.
+func aw55IsCurveRecord(data []byte, addr uint32) bool {
+	if addr%2 != 0 {
+		return false
+	}
+	prev := -1
+	for k := 0; k < aw55CurvePoints; k++ {
+		a := int(addr) + 4*k
+		if a >= aw55CalEnd {
+			return false
+		}
+		x := int(data[a])
+		if x == aw55CurveEnd {
+			return k >= aw55MinPoints
+		}
+		if x < prev {
+			return false
+		}
+		prev = x
+	}
+	return false
+}
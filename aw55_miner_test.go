@@ -0,0 +1,129 @@
+package symbol
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+// aw55MinerImage hand-assembles the two kinds of call site the miner reads,
+// a 1-D lookup at 0x2000 and a 2-D one at 0x3000, plus a curve directory at
+// 0x4000, into an otherwise empty Y990 image.
+func aw55MinerImage() []byte {
+	data := make([]byte, aw55Length)
+	be16 := func(a uint32, v ...uint16) {
+		for k, w := range v {
+			binary.BigEndian.PutUint16(data[a+2*uint32(k):], w)
+		}
+	}
+	be32 := func(a uint32, v uint32) { binary.BigEndian.PutUint32(data[a:], v) }
+	// movl assembles mov.l @(disp,PC),Rn at pc for the literal at lit.
+	movl := func(pc uint32, rn int, lit, v uint32) uint16 {
+		be32(lit, v)
+		return 0xD000 | uint16(rn)<<8 | uint16((lit-(pc&^3+4))/4)
+	}
+
+	binary.BigEndian.PutUint32(data[aw55CalBase:], aw55Magic)
+	copy(data[0x7F000:], "$Workfile: LmY990.c $")
+	be16(0x71000, 0, 10, 20, 30) // curve axis
+	be16(0x71010, 5, 6, 7, 8)
+	be16(0x72000, 100, 200, 300) // map x axis
+	be16(0x72010, 50, 40)        // map y axis, descending
+	be16(0x72020, 1, 2, 3, 4, 5, 6)
+
+	be16(0x1000, 0x000B, 0x0009) // lookup: rts; nop
+	be16(0x1100, 0x000B, 0x0009) // lookup2d
+
+	be16(0x2000,
+		0xC583, // mov.w @(0x106,GBR),R0
+		0x6403, // mov R0,R4
+		movl(0x2004, 5, 0x2020, 0x71000),
+		movl(0x2006, 6, 0x2024, 0x71010),
+		movl(0x2008, 1, 0x2028, 0x1000),
+		0x410B, // jsr @R1
+		0xE704, // mov #4,R7 (delay slot)
+		0x000B, 0x0009)
+
+	be16(0x3000,
+		movl(0x3000, 1, 0x3100, 0x72020),
+		0x2F16, // mov.l R1,@-R15: data
+		0xE202, // mov #2,R2
+		0x2F26, // nrows
+		movl(0x3008, 3, 0x3104, 0x72010),
+		0x2F36, // yaxis
+		0xC510, // mov.w @(0x20,GBR),R0
+		0x6403,
+		movl(0x3010, 5, 0x3108, 0x72000),
+		0xE603, // mov #3,R6
+		0xC520, // mov.w @(0x40,GBR),R0
+		0x6703,
+		movl(0x3018, 1, 0x310C, 0x1100),
+		0x410B,
+		0x0009,
+		0x7F0C, // add #12,R15
+		0x000B, 0x0009)
+
+	for k := uint32(0); k < 10; k++ {
+		rec := 0x7E000 + 0x10*k
+		be32(0x4000+4*k, rec)
+		copy(data[rec:], []byte{0, 0, 0, byte(k), 50, 0, 0, 100, aw55CurveEnd})
+	}
+	be32(0x7FFF0, aw55CalSum(data))
+	return data
+}
+
+func TestMineAW55(t *testing.T) {
+	data := aw55MinerImage()
+	out, err := MineAW55(data, AW55MineOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var defs aw55Defs
+	if err := json.Unmarshal(out, &defs); err != nil {
+		t.Fatal(err)
+	}
+	want := []aw55Definition{
+		{N: 0, Kind: "curve", Data: 0x71010, Rows: 1, Cols: 4, X: 0x71000},
+		{N: 1, Kind: "map", Data: 0x72020, Rows: 2, Cols: 3, X: 0x72000, Y: 0x72010},
+	}
+	if defs.Family != "Y990" || !slices.Equal(defs.Maps, want) {
+		t.Fatalf("mined %+v", defs)
+	}
+	for addr, src := range map[uint32]string{0x71000: "0x106", 0x72000: "0x20", 0x72010: "0x40"} {
+		if ax := defs.Axes[strconv.FormatUint(uint64(addr), 10)]; ax.Src != src {
+			t.Errorf("axis %X: src %q, want %q", addr, ax.Src, src)
+		}
+	}
+	if !slices.Equal(defs.CurveDirs, []uint32{0x4000}) {
+		t.Errorf("curve directories %X", defs.CurveDirs)
+	}
+
+	// Pinning the routines must give the same result.
+	pinned, err := MineAW55(data, AW55MineOpts{Lookup: 0x1000, Lookup2D: 0x1100})
+	if err != nil || string(pinned) != string(out) {
+		t.Fatalf("pinned routines: %v", err)
+	}
+
+	t.Cleanup(func() {
+		aw55FamiliesMu.Lock()
+		delete(aw55Families, "Y990")
+		aw55FamiliesMu.Unlock()
+	})
+	if err := RegisterAW55Family("Y990", out); err != nil {
+		t.Fatal(err)
+	}
+	fw, err := NewAW55File(data, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ax := GetFileInfo(fw, "Symbol-1")
+	x, y, z, _, _, _, err := fw.GetXYZ(ax.X, ax.Y, ax.Z)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(x) != 3 || len(y) != 2 || len(z) != 6 {
+		t.Fatalf("Symbol-1 is %dx%d with %d values", len(y), len(x), len(z))
+	}
+}
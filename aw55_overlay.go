@@ -0,0 +1,203 @@
+package symbol
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// AW55Overlay is what has been worked out by hand about a calibration family:
+// names, scaling and descriptions for the maps the miner can only number. It
+// is keyed by the name NewAW55File generates ("Symbol-17", "Axis-7A3C4",
+// "ShiftUp-0-1", ...), which depends only on the family definition, so one
+// versioned file per family carries the team's knowledge from dump to dump.
+type AW55Overlay struct {
+	Family  string                      `json:"family"`
+	Symbols map[string]AW55OverlayEntry `json:"symbols"`
+}
+
+// AW55OverlayEntry overrides one symbol. Zero fields keep the generated value.
+// The description of a map ends up as its ZDescription, that of an axis as
+// the X or YDescription of every map it belongs to.
+type AW55OverlayEntry struct {
+	Name             string  `json:"name,omitempty"`
+	Correctionfactor float64 `json:"correctionfactor,omitempty"`
+	Unit             string  `json:"unit,omitempty"`
+	Description      string  `json:"description,omitempty"`
+}
+
+type aw55Default struct {
+	name             string
+	correctionfactor float64
+	unit             string
+}
+
+// aw55Layout is one map or curve and the symbols on its axes.
+type aw55Layout struct {
+	x, y, z *Symbol
+}
+
+func ParseAW55Overlay(data []byte) (*AW55Overlay, error) {
+	var o AW55Overlay
+	if err := json.Unmarshal(data, &o); err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+func LoadAW55Overlay(filename string) (*AW55Overlay, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return ParseAW55Overlay(data)
+}
+
+// Save writes the overlay as indented JSON. encoding/json sorts map keys, so
+// the file diffs cleanly under version control.
+func (o *AW55Overlay) Save(filename string) error {
+	data, err := json.MarshalIndent(o, "", " ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, append(data, '\n'), 0o644)
+}
+
+// WithAW55Overlay applies o on load. It must be for the dump's family.
+func WithAW55Overlay(o *AW55Overlay) AW55FileOpt {
+	return func(aw55 *AW55File) error {
+		aw55.overlay = o
+		return nil
+	}
+}
+
+// WithAW55OverlayFile loads the overlay from filename and applies it.
+func WithAW55OverlayFile(filename string) AW55FileOpt {
+	return func(aw55 *AW55File) error {
+		o, err := LoadAW55Overlay(filename)
+		if err != nil {
+			return err
+		}
+		aw55.overlay = o
+		return nil
+	}
+}
+
+func (f *AW55File) applyOverlay(family string, symbols []*Symbol) error {
+	if f.overlay.Family != family {
+		return fmt.Errorf("overlay is for %q, dump is %q: %w", f.overlay.Family, family, ErrUnknownFamily)
+	}
+	// The renames apply as a set, so two symbols can trade names; only two
+	// ending up with the same name is an error.
+	names := make([]string, len(symbols))
+	taken := make(map[string]string, len(symbols))
+	for i, sym := range symbols {
+		names[i] = sym.Name
+		if e := f.overlay.Symbols[sym.Name]; e.Name != "" {
+			names[i] = e.Name
+		}
+		if other, ok := taken[names[i]]; ok {
+			return fmt.Errorf("overlay names both %s and %s %s", other, sym.Name, names[i])
+		}
+		taken[names[i]] = sym.Name
+	}
+	for i, sym := range symbols {
+		e, ok := f.overlay.Symbols[sym.Name]
+		sym.Name = names[i]
+		if !ok {
+			continue
+		}
+		if e.Correctionfactor != 0 {
+			sym.Correctionfactor = e.Correctionfactor
+		}
+		if e.Unit != "" {
+			sym.Unit = e.Unit
+		}
+		if e.Description != "" {
+			f.description[sym] = e.Description
+		}
+	}
+	return nil
+}
+
+// axisInformation is what GetFileInfo answers from for f.
+func (f *AW55File) axisInformation() AxisInformation {
+	return f.axes
+}
+
+// layoutAxes builds f.axes from the layout, under the symbols' current names.
+func (f *AW55File) layoutAxes() AxisInformation {
+	info := make(AxisInformation, len(f.layout))
+	name := func(s *Symbol) string {
+		if s == nil {
+			return ""
+		}
+		return s.Name
+	}
+	for _, l := range f.layout {
+		info[l.z.Name] = Axis{
+			X:            name(l.x),
+			Y:            name(l.y),
+			Z:            l.z.Name,
+			XDescription: f.description[l.x],
+			YDescription: f.description[l.y],
+			ZDescription: f.description[l.z],
+		}
+	}
+	return info
+}
+
+// Rename renames a symbol and updates the axis information that refers to it.
+func (f *AW55File) Rename(oldName, newName string) error {
+	if err := f.Collection.Rename(oldName, newName); err != nil {
+		return err
+	}
+	f.axes = f.layoutAxes()
+	return nil
+}
+
+// SetDescription sets the description Overlay saves and GetFileInfo reports.
+func (f *AW55File) SetDescription(name, description string) error {
+	sym := f.GetByName(name)
+	if sym == nil {
+		return &SymbolError{ECU: ECU_AW55, Name: name, Err: ErrSymbolNotFound}
+	}
+	if description == "" {
+		delete(f.description, sym)
+	} else {
+		f.description[sym] = description
+	}
+	f.axes = f.layoutAxes()
+	return nil
+}
+
+// Overlay captures every name, correction factor, unit and description that
+// differs from what the definition generates, ready to be saved and applied to
+// the next dump of the same family.
+func (f *AW55File) Overlay() *AW55Overlay {
+	o := &AW55Overlay{
+		Family:  AW55Family(f.data),
+		Symbols: make(map[string]AW55OverlayEntry),
+	}
+	for _, sym := range f.Symbols() {
+		def, ok := f.defaults[sym]
+		if !ok {
+			continue
+		}
+		var e AW55OverlayEntry
+		if sym.Name != def.name {
+			e.Name = sym.Name
+		}
+		if sym.Correctionfactor != def.correctionfactor {
+			e.Correctionfactor = sym.Correctionfactor
+		}
+		if sym.Unit != def.unit {
+			e.Unit = sym.Unit
+		}
+		e.Description = f.description[sym]
+		if e != (AW55OverlayEntry{}) {
+			o.Symbols[def.name] = e
+		}
+	}
+	return o
+}
@@ -0,0 +1,67 @@
+package symbol
+
+import "bytes"
+
+// The SH7058 has 1 MB of flash but the AW55 only uses the lower 512 KB, so a
+// dump is either that half or the whole part. The lower half is laid out as
+//
+//	0x00000-0x08000  boot loader, the eight 4 KB erase blocks EB0-EB7
+//	0x08000-0x70000  application
+//	0x70000-0x80000  calibration, checksummed
+//
+// and the upper half of a 1 MB dump is normally erased, sometimes a copy of
+// the lower half left behind by the programming tool, and occasionally
+// something else entirely.
+
+const aw55BootEnd = 0x8000
+
+// AW55UpperHalf is what the upper 512 KB of a dump holds.
+type AW55UpperHalf string
+
+const (
+	UpperAbsent AW55UpperHalf = "absent" // 512 KB dump
+	UpperBlank  AW55UpperHalf = "blank"  // erased, all 0xFF
+	UpperMirror AW55UpperHalf = "mirror" // a copy of the lower half
+	UpperData   AW55UpperHalf = "data"   // anything else; not interpreted
+)
+
+// AW55Region is one area of the dump, [Start, End).
+type AW55Region struct {
+	Name  string `json:"name"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+}
+
+func aw55UpperHalf(data []byte) AW55UpperHalf {
+	if len(data) <= aw55Length {
+		return UpperAbsent
+	}
+	upper := data[aw55Length:]
+	switch {
+	case bytes.Count(upper, []byte{0xFF}) == len(upper):
+		return UpperBlank
+	case bytes.Equal(upper, data[:aw55Length]):
+		return UpperMirror
+	}
+	return UpperData
+}
+
+// UpperHalf reports what the upper half held when the dump was loaded. A
+// mirror is kept a mirror on save; blank or foreign data is written back as
+// it was.
+func (f *AW55File) UpperHalf() AW55UpperHalf {
+	return f.upper
+}
+
+// Regions maps out the dump.
+func (f *AW55File) Regions() []AW55Region {
+	regions := []AW55Region{
+		{Name: "boot", Start: 0, End: aw55BootEnd},
+		{Name: "application", Start: aw55BootEnd, End: aw55CalBase},
+		{Name: "calibration", Start: aw55CalBase, End: aw55CalEnd},
+	}
+	if f.upper != UpperAbsent {
+		regions = append(regions, AW55Region{Name: "upper-" + string(f.upper), Start: aw55Length, End: len(f.data)})
+	}
+	return regions
+}
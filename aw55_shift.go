@@ -0,0 +1,241 @@
+package symbol
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+)
+
+// The shift schedule is one row of ten record-format curves per mode in the
+// first curve directory (see aw55IsShiftRow): five upshift lines followed by
+// the five matching downshift lines. Each line is output-shaft rpm against the
+// load index, shown in % of 255. ShiftSchedule is a typed view of one row.
+// Editing goes through SetShiftSchedule, which refuses anything the TCM would
+// not expect: an upshift below its downshift, a load axis out of order, or a
+// kickdown step (a repeated load point) that was added or removed.
+
+// ShiftCurve is one shift line.
+type ShiftCurve struct {
+	Load []float64 `json:"load"` // %
+	RPM  []float64 `json:"rpm"`  // output shaft
+}
+
+// ShiftPair is the upshift and downshift line of one gear change. Pair is the
+// position in the row. It is taken to be 0 = 1-2, 1 = 2-3 and so on, but the
+// enum behind the row is not pinned down yet, so Gears is a best guess.
+type ShiftPair struct {
+	Pair int        `json:"pair"`
+	Up   ShiftCurve `json:"up"`
+	Down ShiftCurve `json:"down"`
+}
+
+func (p ShiftPair) Gears() (from, to int) {
+	return p.Pair + 1, p.Pair + 2
+}
+
+// ShiftSchedule is the shift lines of one mode. Mode is the row number the
+// ShiftUp-<mode>-<pair> symbols carry.
+type ShiftSchedule struct {
+	Mode  int         `json:"mode"`
+	Pairs []ShiftPair `json:"pairs"`
+}
+
+type aw55ShiftRow struct {
+	mode   int
+	curves [10]aw55CurveSyms
+}
+
+// ShiftModes lists the modes the dump has a shift schedule for.
+func (f *AW55File) ShiftModes() []int {
+	modes := make([]int, len(f.shifts))
+	for i, r := range f.shifts {
+		modes[i] = r.mode
+	}
+	return modes
+}
+
+func (f *AW55File) shiftRow(mode int) (*aw55ShiftRow, error) {
+	for i := range f.shifts {
+		if f.shifts[i].mode == mode {
+			return &f.shifts[i], nil
+		}
+	}
+	return nil, &SymbolError{ECU: ECU_AW55, Name: fmt.Sprintf("ShiftUp-%d-0", mode), Err: ErrSymbolNotFound}
+}
+
+// ShiftSchedule returns a copy of the shift lines of mode.
+func (f *AW55File) ShiftSchedule(mode int) (*ShiftSchedule, error) {
+	row, err := f.shiftRow(mode)
+	if err != nil {
+		return nil, err
+	}
+	s := &ShiftSchedule{Mode: mode}
+	for k := 0; k < 5; k++ {
+		up, dn := row.curves[k], row.curves[k+5]
+		s.Pairs = append(s.Pairs, ShiftPair{
+			Pair: k,
+			Up:   ShiftCurve{Load: up.x.Float64s(), RPM: up.y.Float64s()},
+			Down: ShiftCurve{Load: dn.x.Float64s(), RPM: dn.y.Float64s()},
+		})
+	}
+	return s, nil
+}
+
+// SetShiftSchedule writes s back to its mode. Every line must keep its number
+// of points, which the record format fixes. Nothing is written unless the
+// whole schedule passes.
+func (f *AW55File) SetShiftSchedule(s *ShiftSchedule) error {
+	row, err := f.shiftRow(s.Mode)
+	if err != nil {
+		return err
+	}
+	if len(s.Pairs) != 5 {
+		return fmt.Errorf("mode %d: %d shift pairs, want 5: %w", s.Mode, len(s.Pairs), ErrShiftSchedule)
+	}
+	var data [10][2][]byte
+	for k, p := range s.Pairs {
+		if p.Pair != k {
+			return fmt.Errorf("mode %d: pair %d at position %d: %w", s.Mode, p.Pair, k, ErrShiftSchedule)
+		}
+		for j, line := range []ShiftCurve{p.Up, p.Down} {
+			c := row.curves[k+5*j]
+			x, err := aw55ShiftLoad(c.x, line.Load)
+			if err != nil {
+				return fmt.Errorf("mode %d: %s: %w", s.Mode, c.y.Name, err)
+			}
+			if len(line.RPM) != len(line.Load) {
+				return &SymbolError{ECU: ECU_AW55, Name: c.y.Name, Address: c.addr, Expected: len(line.Load), Actual: len(line.RPM), Err: ErrDataLength}
+			}
+			y, err := aw55ShiftRPM(c.y, line.RPM)
+			if err != nil {
+				return fmt.Errorf("mode %d: %s: %w", s.Mode, c.y.Name, err)
+			}
+			data[k+5*j] = [2][]byte{x, y}
+		}
+		// Compare what will be stored, not what was asked for.
+		up, dn := row.curves[k].y.BytesToInts(data[k][1]), row.curves[k+5].y.BytesToInts(data[k+5][1])
+		for i := range up {
+			if up[i] < dn[i] {
+				return fmt.Errorf("mode %d pair %d point %d: upshift %g rpm below downshift %g rpm: %w", s.Mode, k, i, p.Up.RPM[i], p.Down.RPM[i], ErrShiftSchedule)
+			}
+		}
+	}
+	for i, c := range row.curves {
+		c.x.SetData(data[i][0])
+		c.y.SetData(data[i][1])
+	}
+	return nil
+}
+
+// aw55ShiftRPM encodes an rpm line, refusing any point its data type cannot
+// hold rather than letting it wrap.
+func aw55ShiftRPM(sym *Symbol, rpm []float64) ([]byte, error) {
+	if sym.Correctionfactor == 0 {
+		return nil, &SymbolError{ECU: ECU_AW55, Name: sym.Name, Address: sym.Address, Err: ErrZeroFactor}
+	}
+	lo, hi := sym.rawRange()
+	for i, v := range rpm {
+		raw := math.Round((v - sym.offset()) / sym.Correctionfactor)
+		if !(raw >= float64(lo) && raw <= float64(hi)) {
+			return nil, fmt.Errorf("point %d: %g rpm out of range: %w", i, v, ErrShiftSchedule)
+		}
+	}
+	return sym.EncodeFloat64s(rpm), nil
+}
+
+// aw55ShiftLoad encodes a load axis, checking it against the one it replaces:
+// same length, in order, below the terminator, and with a step exactly where
+// the old one had a step.
+func aw55ShiftLoad(sym *Symbol, load []float64) ([]byte, error) {
+	old := sym.Ints()
+	if len(load) != len(old) {
+		return nil, &SymbolError{ECU: ECU_AW55, Name: sym.Name, Address: sym.Address, Expected: len(old), Actual: len(load), Err: ErrDataLength}
+	}
+	buf := sym.EncodeFloat64s(load)
+	raw := sym.BytesToInts(buf)
+	for i, v := range raw {
+		if v < 0 || v >= aw55CurveEnd {
+			return nil, fmt.Errorf("point %d: load %g%% out of range: %w", i, load[i], ErrShiftSchedule)
+		}
+		if i == 0 {
+			continue
+		}
+		if v < raw[i-1] {
+			return nil, fmt.Errorf("point %d: load %g%% below the point before: %w", i, load[i], ErrShiftSchedule)
+		}
+		if step, was := v == raw[i-1], old[i] == old[i-1]; step != was {
+			if was {
+				return nil, fmt.Errorf("point %d: kickdown step removed: %w", i, ErrShiftSchedule)
+			}
+			return nil, fmt.Errorf("point %d: load repeated where there was no kickdown step: %w", i, ErrShiftSchedule)
+		}
+	}
+	return buf, nil
+}
+
+// TyreSize is a metric tyre size such as 215/55R16.
+type TyreSize struct {
+	Width  float64 // mm
+	Aspect float64 // sidewall height, % of the width
+	Rim    float64 // inch
+}
+
+var tyreRe = regexp.MustCompile(`^\s*(\d+)\s*/\s*(\d+)\s*Z?R\s*(\d+(?:\.\d+)?)\s*$`)
+
+func ParseTyreSize(s string) (TyreSize, error) {
+	m := tyreRe.FindStringSubmatch(s)
+	if m == nil {
+		return TyreSize{}, fmt.Errorf("invalid tyre size %q", s)
+	}
+	w, _ := strconv.ParseFloat(m[1], 64)
+	a, _ := strconv.ParseFloat(m[2], 64)
+	r, _ := strconv.ParseFloat(m[3], 64)
+	return TyreSize{Width: w, Aspect: a, Rim: r}, nil
+}
+
+// Circumference is the unloaded rolling circumference in metres.
+func (t TyreSize) Circumference() float64 {
+	return math.Pi * (t.Rim*25.4 + 2*t.Width*t.Aspect/100) / 1000
+}
+
+func (t TyreSize) String() string {
+	return fmt.Sprintf("%g/%gR%g", t.Width, t.Aspect, t.Rim)
+}
+
+// Drivetrain converts output-shaft rpm to vehicle speed and back.
+type Drivetrain struct {
+	FinalDrive float64
+	Tyre       TyreSize
+}
+
+// KMH is the vehicle speed at output-shaft speed rpm.
+func (d Drivetrain) KMH(rpm float64) float64 {
+	return rpm / d.FinalDrive * d.Tyre.Circumference() * 60 / 1000
+}
+
+// RPM is the output-shaft speed at vehicle speed kmh.
+func (d Drivetrain) RPM(kmh float64) float64 {
+	return kmh * 1000 / 60 / d.Tyre.Circumference() * d.FinalDrive
+}
+
+// Speeds is the line in km/h.
+func (c ShiftCurve) Speeds(d Drivetrain) []float64 {
+	kmh := make([]float64, len(c.RPM))
+	for i, v := range c.RPM {
+		kmh[i] = d.KMH(v)
+	}
+	return kmh
+}
+
+// SetSpeeds sets the line from speeds in km/h. It does not change the number
+// of points.
+func (c *ShiftCurve) SetSpeeds(d Drivetrain, kmh []float64) error {
+	if len(kmh) != len(c.RPM) {
+		return fmt.Errorf("%d speeds for %d points: %w", len(kmh), len(c.RPM), ErrDataLength)
+	}
+	for i, v := range kmh {
+		c.RPM[i] = d.RPM(v)
+	}
+	return nil
+}
@@ -1,7 +1,14 @@
 package symbol
 
 import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 )
@@ -28,6 +35,11 @@ func TestAW55(t *testing.T) {
 		if got := AW55Family(data); got != f.family {
 			t.Fatalf("family %q, want %q", got, f.family)
 		}
+		// The checksum algorithm is inferred; a stock dump is where it has to
+		// hold.
+		if err := fw.(*AW55File).VerifyChecksum(); err != nil {
+			t.Errorf("%s: %v", f.family, err)
+		}
 		maps2d := 0
 		for _, s := range fw.Symbols() {
 			if len(s.Bytes()) != int(s.Length) {
@@ -77,3 +89,424 @@ func TestAW55(t *testing.T) {
 		t.Logf("%s: %d upshift curves", f.family, shifts)
 	}
 }
+
+// aw55TestImage builds a minimal Y867 image: the calibration magic, the family
+// record, one shift-directory curve at 0x7E000 and a valid checksum at 0x7FFF0.
+func aw55TestImage() []byte {
+	data := make([]byte, aw55Length)
+	binary.BigEndian.PutUint32(data[aw55CalBase:], aw55Magic)
+	copy(data[0x7F000:], "$Workfile: LmY867.c $")
+	binary.BigEndian.PutUint32(data[331052:], 0x7E000)
+	copy(data[0x7E000:], []byte{10, 0, 0, 100, 200, 0, 0x01, 0x2C, aw55CurveEnd})
+	binary.BigEndian.PutUint32(data[0x7FFF0:], aw55CalSum(data))
+	return data
+}
+
+func TestAW55Save(t *testing.T) {
+	fw, err := NewAW55File(aw55TestImage(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := fw.(*AW55File)
+	if f.checksumSlot != 0x7FFF0 {
+		t.Fatalf("checksum slot %X, want 7FFF0", f.checksumSlot)
+	}
+
+	m := f.GetByName("Symbol-0")
+	if err := m.SetData(m.EncodeInts([]int{1, 2, 3, 4, -5})); err != nil {
+		t.Fatal(err)
+	}
+	y := f.GetByName("Curve-7E000")
+	if err := y.SetData(y.EncodeInts([]int{150, 300})); err != nil {
+		t.Fatal(err)
+	}
+	x := f.GetByName("Load-Curve-7E000")
+	if err := x.SetData(x.EncodeInts([]int{20, 200})); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := f.Byte()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := data[m.Address+8 : m.Address+10]; !bytes.Equal(got, []byte{0xFF, 0xFB}) {
+		t.Errorf("map cell 4 = % X, want FF FB", got)
+	}
+	if got := data[0x7E000:0x7E009]; !bytes.Equal(got, []byte{20, 0, 0, 150, 200, 0, 0x01, 0x2C, aw55CurveEnd}) {
+		t.Errorf("curve record = % X", got)
+	}
+	if err := f.VerifyChecksum(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A load index of 0xFF is the terminator, so it would shorten the curve.
+	// The failed write must leave the image as the last good one.
+	x.SetData(x.EncodeInts([]int{20, 255}))
+	m.SetData(m.EncodeInts([]int{9, 9, 9, 9, 9}))
+	if _, err := f.Byte(); !errors.Is(err, ErrDataLength) {
+		t.Fatalf("terminator in x stream: got %v", err)
+	}
+	if got := f.data[m.Address+8 : m.Address+10]; !bytes.Equal(got, []byte{0xFF, 0xFB}) {
+		t.Errorf("failed Byte left map cell 4 = % X", got)
+	}
+}
+
+// A calibration whose checksum is already wrong has no slot to find, and
+// must not be written out with its edits under a stale checksum.
+func TestAW55NoChecksumSlot(t *testing.T) {
+	image := aw55TestImage()
+	image[0x7FFF3]++
+	var warnings []string
+	fw, err := NewAW55File(image, nil, WithAW55EventFunc(func(e LoadEvent) {
+		if e.Kind == EventWarning {
+			warnings = append(warnings, e.Message)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := fw.(*AW55File)
+	m := f.GetByName("Symbol-0")
+	m.SetData(m.EncodeInts([]int{1, 2, 3, 4, 5}))
+	if _, err := f.Byte(); !errors.Is(err, ErrChecksumAreaNotFound) {
+		t.Fatalf("Byte without a checksum slot: %v", err)
+	}
+	out := filepath.Join(t.TempDir(), "tcm.bin")
+	if err := f.Save(out); !errors.Is(err, ErrChecksumAreaNotFound) {
+		t.Fatalf("Save without a checksum slot: %v", err)
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Fatalf("Save wrote the file: %v", err)
+	}
+	if len(warnings) != 1 || f.data[m.Address+9] != 0 {
+		t.Errorf("warnings %q, map cell 4 = %d", warnings, f.data[m.Address+9])
+	}
+}
+
+// A family that pins the checksum slot saves even a file whose checksum was
+// wrong, and puts it right.
+func TestAW55PinnedChecksumSlot(t *testing.T) {
+	var defs map[string]any
+	aw55FamiliesMu.Lock()
+	err := json.Unmarshal(aw55Families["Y867"], &defs)
+	aw55FamiliesMu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defs["family"], defs["checksum"] = "Y866", 0x7FFF0
+	pinned, err := json.Marshal(defs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		aw55FamiliesMu.Lock()
+		delete(aw55Families, "Y866")
+		aw55FamiliesMu.Unlock()
+	})
+	if err := RegisterAW55Family("Y866", pinned); err != nil {
+		t.Fatal(err)
+	}
+
+	image := aw55TestImage()
+	copy(image[0x7F000:], "$Workfile: LmY866.c $")
+	fw, err := NewAW55File(image, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := fw.(*AW55File)
+	if f.checksumSlot != 0x7FFF0 || f.VerifyChecksum() == nil {
+		t.Fatalf("slot %X, checksum verified on a changed workfile", f.checksumSlot)
+	}
+	m := f.GetByName("Symbol-0")
+	m.SetData(m.EncodeInts([]int{1, 2, 3, 4, 5}))
+	data, err := f.Byte()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data[m.Address+9] != 5 {
+		t.Errorf("map cell 4 = %d, want 5", data[m.Address+9])
+	}
+	if err := f.VerifyChecksum(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// A definition symbol over bytes a mined map already holds is left out, so
+// its stale copy cannot overwrite edits to the map on Byte; its name stands
+// for the map in the definition's layout.
+func TestAW55DefinitionOverlap(t *testing.T) {
+	def := &Definition{
+		Title: "overlap",
+		Symbols: []*Symbol{
+			{Name: "Boost", Address: 0x7016E, Length: 10, Correctionfactor: 1},
+			{Name: "BoostAxis", Address: 0x70400, Length: 10, Correctionfactor: 1},
+			{Name: "BoostRef", Address: 0x70500, Length: 10, Correctionfactor: 1},
+		},
+		Axes: AxisInformation{"BoostRef": {X: "Boost", Z: "BoostRef"}},
+	}
+	fw, err := NewAW55File(aw55TestImage(), nil, WithAW55Definition(def))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fw.GetByName("Boost") != nil || fw.GetByName("BoostAxis") == nil {
+		t.Fatal("overlapping definition symbol loaded")
+	}
+	if ax := GetFileInfo(fw, "BoostRef"); ax.X != "Symbol-0" {
+		t.Errorf("BoostRef x axis %q, want Symbol-0", ax.X)
+	}
+	m := fw.GetByName("Symbol-0")
+	m.SetData(m.EncodeInts([]int{1, 2, 3, 4, 5}))
+	data, err := fw.(*AW55File).Byte()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := data[m.Address : m.Address+10]; !bytes.Equal(got, []byte{0, 1, 0, 2, 0, 3, 0, 4, 0, 5}) {
+		t.Errorf("Symbol-0 written as % X", got)
+	}
+}
+
+// An overlay renames and scales by generated name, and Overlay gives back
+// exactly what was applied plus whatever was edited since.
+func TestAW55Overlay(t *testing.T) {
+	in := &AW55Overlay{
+		Family: "Y867",
+		Symbols: map[string]AW55OverlayEntry{
+			"Symbol-0":    {Name: "OilTempComp", Correctionfactor: 0.1, Unit: "%", Description: "line pressure trim"},
+			"Curve-7E000": {Unit: "rpm"},
+		},
+	}
+	fw, err := NewAW55File(aw55TestImage(), nil, WithAW55Overlay(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := fw.(*AW55File)
+	s := f.GetByName("OilTempComp")
+	if s == nil || s.Correctionfactor != 0.1 || s.Unit != "%" {
+		t.Fatalf("overlay not applied: %v", s)
+	}
+	if f.GetByName("Symbol-0") != nil {
+		t.Fatal("old name still resolves")
+	}
+	ax := GetFileInfo(f, "OilTempComp")
+	if ax.Z != "OilTempComp" || ax.ZDescription != "line pressure trim" || ax.X == "" {
+		t.Fatalf("axis info %+v", ax)
+	}
+
+	if err := f.Rename(ax.X, "OilTemp"); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.SetDescription("OilTemp", "transmission oil temperature"); err != nil {
+		t.Fatal(err)
+	}
+	if ax := GetFileInfo(f, "OilTempComp"); ax.X != "OilTemp" || ax.XDescription != "transmission oil temperature" {
+		t.Fatalf("rename not reflected: %+v", ax)
+	}
+
+	out := f.Overlay()
+	if out.Family != "Y867" || len(out.Symbols) != 3 {
+		t.Fatalf("overlay %+v", out)
+	}
+	if out.Symbols["Symbol-0"] != in.Symbols["Symbol-0"] || out.Symbols["Curve-7E000"] != in.Symbols["Curve-7E000"] {
+		t.Fatalf("applied entries changed: %+v", out.Symbols)
+	}
+
+	if _, err := NewAW55File(aw55TestImage(), nil, WithAW55Overlay(&AW55Overlay{Family: "Y802"})); !errors.Is(err, ErrUnknownFamily) {
+		t.Fatalf("overlay for another family: got %v", err)
+	}
+
+	// The overlay and the renames belong to f alone, not to the next dump
+	// loaded, nor to the ECU type.
+	plain, err := NewAW55File(aw55TestImage(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ax := GetFileInfo(plain, "Symbol-0"); ax.X == "" || ax.X == "OilTemp" {
+		t.Fatalf("second file sees %+v", ax)
+	}
+	if ax := GetFileInfo(f, "OilTempComp"); ax.X != "OilTemp" {
+		t.Fatalf("first file lost its rename: %+v", ax)
+	}
+	if ax := GetInfo(ECU_AW55, "OilTempComp"); ax.X != "" {
+		t.Fatalf("rename leaked into GetInfo: %+v", ax)
+	}
+	if ax := GetInfo(ECU_AW55, "Symbol-0"); ax.X == "" || ax.X == "OilTemp" {
+		t.Fatalf("GetInfo lost the generated layout: %+v", ax)
+	}
+
+	// Two symbols may trade names; two may not end up with the same one.
+	swapped, err := NewAW55File(aw55TestImage(), nil, WithAW55Overlay(&AW55Overlay{Family: "Y867", Symbols: map[string]AW55OverlayEntry{
+		"Symbol-0": {Name: "Symbol-1"},
+		"Symbol-1": {Name: "Symbol-0"},
+	}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := swapped.GetByName("Symbol-1"); s == nil || s.Number != plain.GetByName("Symbol-0").Number {
+		t.Fatalf("swap: Symbol-1 is %v", s)
+	}
+	if _, err := NewAW55File(aw55TestImage(), nil, WithAW55Overlay(&AW55Overlay{Family: "Y867", Symbols: map[string]AW55OverlayEntry{
+		"Symbol-0": {Name: "Symbol-1"},
+	}})); err == nil {
+		t.Fatal("rename onto a taken name accepted")
+	}
+}
+
+func TestLoadAW55Families(t *testing.T) {
+	dir := t.TempDir()
+	def := bytes.Replace(aw55Y867, []byte(`"family": "Y867"`), []byte(`"family": "Y999"`), 1)
+	if err := os.WriteFile(dir+"/aw55_Y999.json", def, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		aw55FamiliesMu.Lock()
+		delete(aw55Families, "Y999")
+		aw55FamiliesMu.Unlock()
+	})
+
+	data := aw55TestImage()
+	copy(data[0x7F000:], "$Workfile: LmY999.c $")
+	if _, err := NewAW55File(data, nil); !errors.Is(err, ErrUnknownFamily) {
+		t.Fatalf("before registering: got %v", err)
+	}
+	names, err := LoadAW55Families(dir)
+	if err != nil || len(names) != 1 || names[0] != "Y999" {
+		t.Fatalf("LoadAW55Families = %v, %v", names, err)
+	}
+	if _, err := NewAW55File(data, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterAW55Family("Y998", def); err == nil {
+		t.Fatal("registered a Y999 definition as Y998")
+	}
+}
+
+// aw55ShiftImage is aw55TestImage with a shift row for mode 0 in the shift
+// directory: six points per line, with a kickdown step at the top.
+func aw55ShiftImage() []byte {
+	data := aw55TestImage()
+	for k := 0; k < 10; k++ {
+		rec := uint32(0x7E100 + 0x20*k)
+		binary.BigEndian.PutUint32(data[331052+4*k:], rec)
+		rpm := 1000 + 200*(k%5)
+		if k >= 5 {
+			rpm -= 300
+		}
+		for j, x := range []byte{0, 50, 100, 150, 230, 230} {
+			a := int(rec) + 4*j
+			data[a] = x
+			v := rpm + 100*j
+			if j == 5 {
+				v += 1000
+			}
+			binary.BigEndian.PutUint16(data[a+2:], uint16(v))
+		}
+		data[int(rec)+24] = aw55CurveEnd
+	}
+	binary.BigEndian.PutUint32(data[0x7FFF0:], 0)
+	binary.BigEndian.PutUint32(data[0x7FFF0:], aw55CalSum(data))
+	return data
+}
+
+func TestAW55ShiftSchedule(t *testing.T) {
+	fw, err := NewAW55File(aw55ShiftImage(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := fw.(*AW55File)
+	if modes := f.ShiftModes(); len(modes) != 1 || modes[0] != 0 {
+		t.Fatalf("modes %v", modes)
+	}
+	s, err := f.ShiftSchedule(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := s.Pairs[1]
+	if from, to := p.Gears(); from != 2 || to != 3 || p.Up.RPM[0] != 1200 || p.Down.RPM[0] != 900 {
+		t.Fatalf("pair 1 = %+v", p)
+	}
+
+	d := Drivetrain{FinalDrive: 3.65}
+	if d.Tyre, err = ParseTyreSize("215/55R16"); err != nil {
+		t.Fatal(err)
+	}
+	kmh := p.Up.Speeds(d)
+	if math.Abs(kmh[0]-39.84) > 0.01 {
+		t.Fatalf("1200 rpm = %.2f km/h", kmh[0])
+	}
+	kmh[0] += 5
+	if err := s.Pairs[1].Up.SetSpeeds(d, kmh); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.SetShiftSchedule(s); err != nil {
+		t.Fatal(err)
+	}
+	if got := f.GetByName("ShiftUp-0-1").Ints()[0]; got != 1351 {
+		t.Fatalf("ShiftUp-0-1[0] = %d, want 1351", got)
+	}
+
+	for name, edit := range map[string]func(s *ShiftSchedule){
+		"hysteresis":      func(s *ShiftSchedule) { s.Pairs[2].Down.RPM[3] = s.Pairs[2].Up.RPM[3] + 1 },
+		"order":           func(s *ShiftSchedule) { s.Pairs[0].Up.Load[2] = 10 },
+		"step removed":    func(s *ShiftSchedule) { s.Pairs[0].Up.Load[5] = 95 },
+		"step added":      func(s *ShiftSchedule) { s.Pairs[0].Up.Load[1] = s.Pairs[0].Up.Load[0] },
+		"terminator load": func(s *ShiftSchedule) { s.Pairs[0].Up.Load[4], s.Pairs[0].Up.Load[5] = 100, 100 },
+		"rpm wraps":       func(s *ShiftSchedule) { s.Pairs[1].Up.RPM[0] = 40000 },
+		"rpm below type":  func(s *ShiftSchedule) { s.Pairs[1].Down.RPM[0] = -40000 },
+	} {
+		s, _ := f.ShiftSchedule(0)
+		edit(s)
+		if err := f.SetShiftSchedule(s); !errors.Is(err, ErrShiftSchedule) {
+			t.Errorf("%s: got %v", name, err)
+		}
+	}
+	if got := f.GetByName("ShiftUp-0-1").Ints()[0]; got != 1351 {
+		t.Fatal("a refused schedule was partly written")
+	}
+}
+
+func TestAW55Regions(t *testing.T) {
+	lower := aw55TestImage()
+	for _, tc := range []struct {
+		upper []byte
+		want  AW55UpperHalf
+	}{
+		{nil, UpperAbsent},
+		{bytes.Repeat([]byte{0xFF}, aw55Length), UpperBlank},
+		{lower, UpperMirror},
+		{make([]byte, aw55Length), UpperData},
+	} {
+		data := append(slices.Clone(lower), tc.upper...)
+		fw, err := NewAW55File(data, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f := fw.(*AW55File)
+		if f.UpperHalf() != tc.want {
+			t.Fatalf("upper half %s, want %s", f.UpperHalf(), tc.want)
+		}
+		regions := f.Regions()
+		if last := regions[len(regions)-1]; last.End != len(data) {
+			t.Fatalf("%s: regions end at %X", tc.want, last.End)
+		}
+
+		m := f.GetByName("Symbol-0")
+		m.SetData(m.EncodeInts([]int{7, 7, 7, 7, 7}))
+		out, err := f.Byte()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(out) != len(data) {
+			t.Fatalf("%s: saved %d bytes from %d", tc.want, len(out), len(data))
+		}
+		switch tc.want {
+		case UpperMirror:
+			if !bytes.Equal(out[aw55Length:], out[:aw55Length]) {
+				t.Fatal("mirror not kept in step")
+			}
+		case UpperBlank, UpperData:
+			if !bytes.Equal(out[aw55Length:], tc.upper) {
+				t.Fatalf("%s upper half changed", tc.want)
+			}
+		}
+	}
+}
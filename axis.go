@@ -2,6 +2,7 @@ package symbol
 
 import (
 	"fmt"
+	"sync"
 )
 
 type AxisInformation map[string]Axis
@@ -29,12 +30,63 @@ var axisTranslator = map[ECUType]AxisInformation{
 	ECU_T8: axisT8,
 }
 
+// aw55Axes is what GetAxisCollection answers for ECU_AW55: the layout of the
+// last AW55 file loaded, under the names its definition generates. Renames
+// and overlays stay with their file, where GetFileInfo sees them.
+var (
+	aw55Axes   AxisInformation
+	aw55AxesMu sync.RWMutex
+)
+
 func GetAxisCollection(ecu ECUType) AxisInformation {
+	if ecu == ECU_AW55 {
+		aw55AxesMu.RLock()
+		defer aw55AxesMu.RUnlock()
+		return aw55Axes
+	}
 	return axisTranslator[ecu]
 }
 
+// fileAxes is a file that lays out symbols of its own, from a definition or
+// the AW55 mined maps and their overlay, beyond the tables of its ECU type.
+type fileAxes interface {
+	axisInformation() AxisInformation
+}
+
+// GetFileAxisCollection is GetAxisCollection for fw: that of its ECU type
+// with the file's own layout on top.
+func GetFileAxisCollection(fw FirmwareFile) AxisInformation {
+	info := make(AxisInformation)
+	for name, ax := range GetAxisCollection(ecuOf(fw)) {
+		info[name] = ax
+	}
+	if f, ok := fw.(fileAxes); ok {
+		for name, ax := range f.axisInformation() {
+			info[name] = ax
+		}
+	}
+	return info
+}
+
+// GetFileInfo is GetInfo for a symbol of fw, looked up in the file's own
+// layout before that of its ECU type.
+func GetFileInfo(fw FirmwareFile, name string) Axis {
+	return fileInfo(ecuOf(fw), fw, name)
+}
+
+// fileInfo is GetFileInfo for callers that know the ECU type of a fw that
+// does not carry it, such as a bare Collection.
+func fileInfo(ecu ECUType, fw FirmwareFile, name string) Axis {
+	if f, ok := fw.(fileAxes); ok {
+		if ax, ok := f.axisInformation()[name]; ok {
+			return ax
+		}
+	}
+	return GetInfo(ecu, name)
+}
+
 func getAxis(ecu ECUType, name string) Axis {
-	return axisTranslator[ecu][name]
+	return GetAxisCollection(ecu)[name]
 }
 
 // returns x, y, z axis map name
@@ -0,0 +1,110 @@
+package symbol
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Dependents lists the maps whose X or Y axis is the symbol axis, according to
+// the layout of fw, that fw actually has.
+func Dependents(fw FirmwareFile, axis string) []string {
+	var out []string
+	for name, ax := range GetFileAxisCollection(fw) {
+		if (ax.X == axis || ax.Y == axis) && fw.GetByName(ax.Z) != nil {
+			out = append(out, name)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// RescaleAxis moves the breakpoints of the axis symbol to breakpoints, given
+// in physical units, and re-interpolates every dependent map so that it still
+// gives the same value at each input it gave a value for before: a new
+// breakpoint takes what the old map interpolated there, and one beyond the old
+// axis ends takes the clamped edge value, which is what the ECU used. The
+// number of breakpoints is fixed by the symbol's size in flash.
+//
+// It returns the maps it rewrote, each with the cells that saturated. Nothing
+// is written if the axis or any dependent cannot be rescaled.
+func RescaleAxis(fw FirmwareFile, axis string, breakpoints []float64) (map[string][]Cell, error) {
+	ecu := ecuOf(fw)
+	sym := fw.GetByName(axis)
+	if sym == nil {
+		return nil, &SymbolError{ECU: ecu, Name: axis, Err: ErrSymbolNotFound}
+	}
+	if n := len(sym.Ints()); len(breakpoints) != n {
+		return nil, &SymbolError{ECU: ecu, Name: axis, Address: sym.Address, Expected: n, Actual: len(breakpoints), Err: ErrDataLength}
+	}
+	for i := 1; i < len(breakpoints); i++ {
+		if (breakpoints[i]-breakpoints[i-1])*(breakpoints[1]-breakpoints[0]) <= 0 {
+			return nil, fmt.Errorf("%s: breakpoint %d (%g) out of order: %w", axis, i, breakpoints[i], ErrAxisOrder)
+		}
+	}
+	if sym.Correctionfactor == 0 {
+		return nil, &SymbolError{ECU: ecu, Name: axis, Address: sym.Address, Err: ErrZeroFactor}
+	}
+	// The axis has to hold the new breakpoints exactly, or the maps would
+	// be interpolated at inputs it does not have.
+	lo, hi := sym.rawRange()
+	offset := sym.offset()
+	for i, v := range breakpoints {
+		if raw := math.Round((v - offset) / sym.Correctionfactor); raw < float64(lo) || raw > float64(hi) {
+			return nil, fmt.Errorf("%s: breakpoint %d (%g) does not fit the axis: %w", axis, i, v, ErrOffsetOutOfRange)
+		}
+	}
+	axisData := sym.EncodeFloat64s(breakpoints)
+	exact := physical(sym.BytesToInts(axisData), sym.Correctionfactor, offset)
+
+	type rescaled struct {
+		m         *Map
+		z         [][]float64
+		data      []byte
+		saturated []Cell
+	}
+	var todo []rescaled
+	for _, name := range Dependents(fw, axis) {
+		m, err := NewMap(fw, name)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		xs, ys := m.X, m.Y
+		if m.XName == axis {
+			xs = exact
+		}
+		if m.YName == axis {
+			ys = exact
+		}
+		if len(xs) != m.Cols() || len(ys) != m.Rows() {
+			return nil, fmt.Errorf("%s: axis %s has %d points, map is %dx%d: %w", name, axis, len(exact), m.Rows(), m.Cols(), ErrDataLength)
+		}
+		z := make([][]float64, len(ys))
+		for r, y := range ys {
+			z[r] = make([]float64, len(xs))
+			for c, x := range xs {
+				z[r][c] = m.Lookup(x, y)
+			}
+		}
+		// Encode every dependent before storing any, so one that cannot
+		// take its new values leaves the file as it was.
+		data, saturated, err := m.encode(z)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		todo = append(todo, rescaled{m, z, data, saturated})
+	}
+	if len(axisData) != int(sym.Length) {
+		return nil, &SymbolError{ECU: ecu, Name: axis, Address: sym.Address, Expected: int(sym.Length), Actual: len(axisData), Err: ErrDataLength}
+	}
+
+	out := make(map[string][]Cell, len(todo))
+	for _, t := range todo {
+		// Cannot fail: the length was checked by encode.
+		t.m.z.SetData(t.data)
+		t.m.Z = t.z
+		out[t.m.Name] = t.saturated
+	}
+	sym.SetData(axisData)
+	return out, nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	symbol "github.com/roffe/ecusymbol"
+)
+
+type checksumOutput struct {
+	Area     string `json:"area"`
+	Valid    bool   `json:"valid"`
+	Fixed    bool   `json:"fixed,omitempty"`
+	Expected string `json:"expected,omitempty"`
+	Actual   string `json:"actual,omitempty"`
+}
+
+func (c checksumOutput) String() string {
+	switch {
+	case c.Valid:
+		return "ok"
+	case c.Fixed:
+		return fmt.Sprintf("MISMATCH, corrected in memory (file has %s, expected %s)", c.Actual, c.Expected)
+	}
+	return fmt.Sprintf("MISMATCH (file has %s, expected %s)", c.Actual, c.Expected)
+}
+
+// checksums are the verdicts loading the file reported, one per area.
+func (b *firmware) checksums() []checksumOutput {
+	out := []checksumOutput{}
+	for _, e := range b.events {
+		if e.Kind != symbol.EventChecksum {
+			continue
+		}
+		out = append(out, checksumOutput{
+			Area:     e.Area,
+			Valid:    e.Valid,
+			Fixed:    e.Fixed,
+			Expected: fmt.Sprintf("%X", e.Expected),
+			Actual:   fmt.Sprintf("%X", e.Actual),
+		})
+	}
+	return out
+}
+
+func runChecksum(args []string) error {
+	o := newFlags("checksum", true)
+	verb := o.verb(args)
+	args = o.args(args[1:], 1, false)
+	b, err := load(args[0], o.verbose)
+	if err != nil {
+		return err
+	}
+	sums := b.checksums()
+	switch verb {
+	case "verify":
+	case "fix":
+		out, err := o.output(b.path)
+		if err != nil {
+			return err
+		}
+		// Save recalculates every checksum of the ECU type before writing.
+		if err := b.fw.Save(out); err != nil {
+			return err
+		}
+		return saved(o, out)
+	default:
+		return fmt.Errorf("checksum %s: want verify or fix", verb)
+	}
+
+	if o.json {
+		if err := printJSON(sums); err != nil {
+			return err
+		}
+	} else {
+		lines := []string{}
+		for _, c := range sums {
+			lines = append(lines, c.Area+"\t"+c.String())
+		}
+		if len(lines) == 0 {
+			lines = append(lines, "no checksum reported for "+b.ecu.String())
+		}
+		if err := table(lines...); err != nil {
+			return err
+		}
+	}
+	for _, c := range sums {
+		if !c.Valid {
+			return errors.New("checksum mismatch")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	symbol "github.com/roffe/ecusymbol"
+)
+
+type candidateOutput struct {
+	Name        string  `json:"name"`
+	Address     uint32  `json:"address"`
+	Rows        int     `json:"rows"`
+	Cols        int     `json:"cols"`
+	ElementSize int     `json:"elementSize"`
+	X           uint32  `json:"x"`
+	Y           uint32  `json:"y,omitempty"`
+	Header      bool    `json:"header"`
+	Confidence  float64 `json:"confidence"`
+}
+
+// runDetect works on the raw file, so it takes binaries no loader knows.
+func runDetect(args []string) error {
+	o := newFlags("detect", false)
+	minConf := o.Float64("min", 0.5, "only candidates of at least `confidence`")
+	args = o.args(args, 1, false)
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return err
+	}
+	out := []candidateOutput{}
+	for _, c := range symbol.DetectMaps(data, symbol.WithDetectMinConfidence(*minConf)) {
+		co := candidateOutput{c.Name, c.Address, c.Rows, c.Cols, c.ElementSize, c.X.Address, 0, c.Header, c.Confidence}
+		if c.Y != nil {
+			co.Y = c.Y.Address
+		}
+		out = append(out, co)
+	}
+	if o.json {
+		return printJSON(out)
+	}
+	lines := []string{"name\taddress\tsize\telement\tx axis\ty axis\theader\tconfidence"}
+	for _, c := range out {
+		y := "-"
+		if c.Y != 0 {
+			y = fmt.Sprintf("0x%06X", c.Y)
+		}
+		lines = append(lines, fmt.Sprintf("%s\t0x%06X\t%dx%d\t%d\t0x%06X\t%s\t%v\t%.2f", c.Name, c.Address, c.Rows, c.Cols, c.ElementSize, c.X, y, c.Header, c.Confidence))
+	}
+	lines = append(lines, fmt.Sprintf("\n%d candidates", len(out)))
+	return table(lines...)
+}
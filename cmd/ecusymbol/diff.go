@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+
+	symbol "github.com/roffe/ecusymbol"
+)
+
+func runDiff(args []string) error {
+	o := newFlags("diff", false)
+	args = o.args(args, 2, false)
+	a, err := load(args[0], o.verbose)
+	if err != nil {
+		return err
+	}
+	b, err := load(args[1], o.verbose)
+	if err != nil {
+		return err
+	}
+	d, err := symbol.Compare(a.fw, b.fw)
+	if err != nil {
+		return err
+	}
+	if o.json {
+		return printJSON(d)
+	}
+
+	var lines []string
+	for _, name := range d.OnlyInA {
+		lines = append(lines, "only in "+a.path+"\t"+name)
+	}
+	for _, name := range d.OnlyInB {
+		lines = append(lines, "only in "+b.path+"\t"+name)
+	}
+	for _, m := range d.Moved {
+		lines = append(lines, fmt.Sprintf("moved\t%s\t0x%06X/%d -> 0x%06X/%d", m.Name, m.AddressA, m.LengthA, m.AddressB, m.LengthB))
+	}
+	for _, c := range d.Changed {
+		lines = append(lines, fmt.Sprintf("changed\t%s\t%d bytes", c.Name, c.Bytes))
+		for _, cell := range c.Cells {
+			lines = append(lines, fmt.Sprintf("\t  [%d,%d]\t%g -> %g (%+g)", cell.Row, cell.Col, cell.A, cell.B, cell.Delta))
+		}
+	}
+	for _, r := range d.Raw {
+		lines = append(lines, fmt.Sprintf("raw\t0x%06X-0x%06X\t%d bytes outside any symbol", r.Start, r.End, r.End-r.Start))
+	}
+	if len(lines) == 0 {
+		lines = append(lines, "no differences")
+	}
+	return table(lines...)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	symbol "github.com/roffe/ecusymbol"
+)
+
+func runExport(args []string) error {
+	o := newFlags("export", false)
+	format := o.String("f", "", "`format`: xdf, ecuflash, winols, csv or json")
+	name := o.String("map", "", "the map to export, for csv and json")
+	file := o.String("o", "", "write to `file` instead of stdout")
+	args = o.args(args, 1, false)
+	b, err := load(args[0], o.verbose)
+	if err != nil {
+		return err
+	}
+
+	var out []byte
+	switch strings.ToLower(*format) {
+	case "xdf":
+		title := strings.TrimSuffix(filepath.Base(b.path), filepath.Ext(b.path))
+		out, err = symbol.ExportXDF(b.fw, title)
+	case "ecuflash":
+		out, err = symbol.ExportECUFlash(b.fw, nil)
+	case "winols":
+		out, err = symbol.ExportWinOLS(b.fw)
+	case "csv", "json":
+		if *name == "" {
+			return errors.New("-map is required for csv and json")
+		}
+		out, err = symbol.ExportMap(b.fw, *name, symbol.MapFormat(strings.ToLower(*format)))
+	default:
+		return fmt.Errorf("export format %q: %w", *format, symbol.ErrUnknownFormat)
+	}
+	if err != nil {
+		return err
+	}
+	if *file == "" {
+		_, err = os.Stdout.Write(out)
+		return err
+	}
+	if err := os.WriteFile(*file, out, 0o644); err != nil {
+		return err
+	}
+	return saved(o, *file)
+}
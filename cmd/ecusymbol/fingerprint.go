@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	symbol "github.com/roffe/ecusymbol"
+)
+
+// defaultFingerprintDB is where fingerprint keeps its database unless -db says
+// otherwise.
+const defaultFingerprintDB = "fingerprints.json"
+
+type matchOutput struct {
+	Stock      string   `json:"stock"`
+	Version    string   `json:"version"`
+	Similarity float64  `json:"similarity"`
+	CodeBlocks int      `json:"codeBlocks"`
+	Differing  int      `json:"differing"`
+	Changed    []string `json:"changed"`
+	Compared   int      `json:"compared"`
+}
+
+func runFingerprint(args []string) error {
+	o := newFlags("fingerprint", false)
+	dbFile := o.String("db", defaultFingerprintDB, "fingerprint database `file`")
+	verb := o.verb(args)
+	args = o.args(args[1:], 1, true)
+	db, err := symbol.LoadFingerprintDB(*dbFile)
+	if err != nil {
+		return err
+	}
+	switch verb {
+	case "add":
+		for _, path := range args {
+			b, err := load(path, o.verbose)
+			if err != nil {
+				return err
+			}
+			fp, err := symbol.NewFingerprint(filepath.Base(path), b.fw)
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			db.Add(fp)
+			if !o.json {
+				fmt.Printf("added %s (%s %s)\n", fp.Name, fp.ECU, orNone(fp.Version))
+			}
+		}
+		if err := db.Save(*dbFile); err != nil {
+			return err
+		}
+		return saved(o, *dbFile)
+	case "identify":
+	default:
+		return fmt.Errorf("fingerprint %s: want add or identify", verb)
+	}
+
+	if len(args) != 1 {
+		return fmt.Errorf("fingerprint identify takes one file, got %d", len(args))
+	}
+	b, err := load(args[0], o.verbose)
+	if err != nil {
+		return err
+	}
+	fp, err := symbol.NewFingerprint(filepath.Base(args[0]), b.fw)
+	if err != nil {
+		return err
+	}
+	out := []matchOutput{}
+	for _, m := range db.Lookup(fp) {
+		out = append(out, matchOutput{m.Entry.Name, m.Entry.Version, m.Similarity, m.CodeBlocks, m.Differing, append([]string{}, m.Changed...), m.Compared})
+	}
+	if o.json {
+		return printJSON(out)
+	}
+	if len(out) == 0 {
+		return fmt.Errorf("no %s of %d bytes in %s", fp.ECU, fp.Size, *dbFile)
+	}
+	lines := []string{"stock\tversion\tcode\tcalibration"}
+	for _, m := range out {
+		changed := fmt.Sprintf("%d of %d symbols differ", len(m.Changed), m.Compared)
+		if len(m.Changed) > 0 && len(m.Changed) <= 8 {
+			changed += ": " + strings.Join(m.Changed, ", ")
+		}
+		lines = append(lines, fmt.Sprintf("%s\t%s\t%.1f%% (%d of %d blocks differ)\t%s",
+			m.Stock, orNone(m.Version), 100*m.Similarity, m.Differing, m.CodeBlocks, changed))
+	}
+	return table(lines...)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	symbol "github.com/roffe/ecusymbol"
+)
+
+type infoOutput struct {
+	File      string                 `json:"file"`
+	ECU       string                 `json:"ecu"`
+	Version   string                 `json:"version"`
+	Size      int                    `json:"size"`
+	Symbols   int                    `json:"symbols"`
+	Names     symbol.NameSource      `json:"names,omitempty"`
+	Checksums []checksumOutput       `json:"checksums"`
+	T7        *symbol.T7FirmwareInfo `json:"t7,omitempty"`
+	Headers   []string               `json:"headers,omitempty"`
+	AW55      *aw55Output            `json:"aw55,omitempty"`
+	Warnings  []string               `json:"warnings,omitempty"`
+}
+
+type aw55Output struct {
+	Family    string               `json:"family"`
+	UpperHalf symbol.AW55UpperHalf `json:"upperHalf"`
+	Regions   []symbol.AW55Region  `json:"regions"`
+}
+
+func runInfo(args []string) error {
+	o := newFlags("info", false)
+	args = o.args(args, 1, false)
+	b, err := load(args[0], o.verbose)
+	if err != nil {
+		return err
+	}
+	out := infoOutput{
+		File:      b.path,
+		ECU:       b.ecu.String(),
+		Version:   b.fw.Version(),
+		Size:      len(b.data),
+		Symbols:   b.fw.Count(),
+		Checksums: b.checksums(),
+	}
+	for _, e := range b.events {
+		switch e.Kind {
+		case symbol.EventNameSource:
+			out.Names = e.Source
+		case symbol.EventWarning, symbol.EventFooterRepair:
+			out.Warnings = append(out.Warnings, e.Message)
+		}
+	}
+	switch f := b.fw.(type) {
+	case *symbol.T7File:
+		info := f.GetInfo()
+		out.T7 = &info
+		for _, h := range f.GetHeaders() {
+			out.Headers = append(out.Headers, h.PrettyString())
+		}
+	case *symbol.AW55File:
+		out.AW55 = &aw55Output{Family: symbol.AW55Family(b.data), UpperHalf: f.UpperHalf(), Regions: f.Regions()}
+	}
+	if o.json {
+		return printJSON(out)
+	}
+
+	lines := []string{
+		"file\t" + out.File,
+		"ecu\t" + out.ECU,
+		"version\t" + out.Version,
+		fmt.Sprintf("size\t%d bytes", out.Size),
+		fmt.Sprintf("symbols\t%d (names: %s)", out.Symbols, orNone(string(out.Names))),
+	}
+	for _, c := range out.Checksums {
+		lines = append(lines, "checksum "+c.Area+"\t"+c.String())
+	}
+	if t7 := out.T7; t7 != nil {
+		lines = append(lines,
+			"software\t"+t7.SoftwareVersion,
+			"part number\t"+t7.Partnumber,
+			"engine\t"+t7.EngineType,
+			"chassis\t"+t7.ChassisID,
+			"immobilizer\t"+t7.ImmobilizerCode,
+			"programmed\t"+t7.ProgrammingDate,
+			fmt.Sprintf("biopower\t%v", t7.BioPowerEnabled),
+		)
+		if len(out.Headers) > 0 {
+			lines = append(lines, "headers\t"+strings.Join(out.Headers, "\n\t"))
+		}
+	}
+	if aw := out.AW55; aw != nil {
+		lines = append(lines, "family\t"+aw.Family, "upper half\t"+string(aw.UpperHalf))
+		for _, r := range aw.Regions {
+			lines = append(lines, fmt.Sprintf("region %s\t0x%06X-0x%06X", r.Name, r.Start, r.End))
+		}
+	}
+	for _, w := range out.Warnings {
+		lines = append(lines, "warning\t"+w)
+	}
+	return table(lines...)
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return s
+}
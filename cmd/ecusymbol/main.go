@@ -0,0 +1,205 @@
+// Command ecusymbol inspects and edits Trionic 5/7/8 and AW55 binaries from
+// the shell: the operations otherwise scripted with throwaway Go programs.
+//
+//	ecusymbol info <bin>
+//	ecusymbol symbols [-match query] <bin>
+//	ecusymbol get <bin> <symbol>
+//	ecusymbol set [-o out | -w] [-cell row,col] <bin> <symbol> <value>...
+//	ecusymbol checksum verify|fix [-o out | -w] <bin>
+//	ecusymbol diff <a> <b>
+//	ecusymbol patch apply [-o out | -w] <bin> <package>
+//	ecusymbol export -f xdf|ecuflash|winols|csv|json [-map name] [-o out] <bin>
+//	ecusymbol scan [-workers n] [-o report [-resume]] [-csv] <dir>
+//	ecusymbol fingerprint add|identify [-db file] <bin>...
+//	ecusymbol stock add|revert [-lib dir] [-o out | -w] <bin> [symbol...]
+//	ecusymbol detect [-min confidence] <bin>
+//
+// Every command prints a table, or JSON with -json.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	symbol "github.com/roffe/ecusymbol"
+)
+
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) error
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{"info", "info <bin>", runInfo},
+		{"symbols", "symbols [-match query] <bin>", runSymbols},
+		{"get", "get <bin> <symbol>", runGet},
+		{"set", "set [-o out | -w] [-cell row,col] [-csv file | -jsonfile file] <bin> <symbol> [value...]", runSet},
+		{"checksum", "checksum verify|fix [-o out | -w] <bin>", runChecksum},
+		{"diff", "diff <a> <b>", runDiff},
+		{"patch", "patch apply [-o out | -w] <bin> <package>", runPatch},
+		{"export", "export -f xdf|ecuflash|winols|csv|json [-map name] [-o out] <bin>", runExport},
+		{"scan", "scan [-workers n] [-o report [-resume]] [-csv] <dir>", runScan},
+		{"fingerprint", "fingerprint add|identify [-db file] <bin>...", runFingerprint},
+		{"stock", "stock add [-lib dir] <bin>... | stock revert [-lib dir] [-o out | -w] <bin> [symbol...]", runStock},
+		{"detect", "detect [-min confidence] <bin>", runDetect},
+	}
+}
+
+func main() {
+	log.SetFlags(0)
+	if len(os.Args) < 2 {
+		usage()
+	}
+	for _, c := range commands {
+		if c.name == os.Args[1] {
+			if err := c.run(os.Args[2:]); err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+	}
+	usage()
+}
+
+func usage() {
+	var b strings.Builder
+	b.WriteString("Usage:\n")
+	for _, c := range commands {
+		fmt.Fprintf(&b, "  ecusymbol %s\n", c.usage)
+	}
+	b.WriteString("Every command takes -json for machine readable output and -v for load messages.")
+	log.Print(b.String())
+	os.Exit(2)
+}
+
+// options are the flags every command has.
+type options struct {
+	*flag.FlagSet
+	json    bool
+	verbose bool
+	out     string
+	inPlace bool
+}
+
+func newFlags(name string, writes bool) *options {
+	o := &options{FlagSet: flag.NewFlagSet(name, flag.ExitOnError)}
+	o.BoolVar(&o.json, "json", false, "print JSON")
+	o.BoolVar(&o.verbose, "v", false, "print load messages")
+	if writes {
+		o.StringVar(&o.out, "o", "", "write the result to `file`")
+		o.BoolVar(&o.inPlace, "w", false, "overwrite the input file")
+	}
+	return o
+}
+
+// args parses the flags and wants n positional arguments, or at least n when
+// more is true.
+func (o *options) args(args []string, n int, more bool) []string {
+	o.Parse(args)
+	if o.NArg() < n || !more && o.NArg() > n {
+		for _, c := range commands {
+			if c.name == o.Name() {
+				log.Printf("Usage: ecusymbol %s", c.usage)
+			}
+		}
+		o.PrintDefaults()
+		os.Exit(2)
+	}
+	return o.Args()
+}
+
+// verb is the word that picks what a command does, given before its flags.
+func (o *options) verb(args []string) string {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		o.args(nil, 1, false) // prints the usage
+	}
+	return args[0]
+}
+
+// output is where a command that modifies bin saves it.
+func (o *options) output(bin string) (string, error) {
+	switch {
+	case o.out != "":
+		return o.out, nil
+	case o.inPlace:
+		return bin, nil
+	}
+	return "", errors.New("refusing to modify the input: give -o file or -w")
+}
+
+// saved reports where a modified file went.
+func saved(o *options, file string) error {
+	if o.json {
+		return printJSON(map[string]string{"saved": file})
+	}
+	fmt.Printf("saved %s\n", file)
+	return nil
+}
+
+// firmware is a loaded file and what loading it reported.
+type firmware struct {
+	path   string
+	data   []byte
+	ecu    symbol.ECUType
+	fw     symbol.FirmwareFile
+	events []symbol.LoadEvent
+}
+
+func load(path string, verbose bool) (*firmware, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	b := &firmware{path: path, data: data}
+	printFunc := func(string) {}
+	if verbose {
+		printFunc = func(s string) { log.Println(s) }
+	}
+	b.ecu, b.fw, err = symbol.Load(path, data, printFunc, symbol.WithLoadEventFunc(func(e symbol.LoadEvent) {
+		b.events = append(b.events, e)
+	}))
+	if err != nil {
+		// T5 and T7 load with a bad checksum, and say so; that is for the
+		// checksum command to report, not a reason to stop.
+		if !errors.Is(err, symbol.ErrChecksumMismatch) || b.fw == nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if verbose {
+			log.Printf("%s: %v", path, err)
+		}
+	}
+	return b, nil
+}
+
+func (b *firmware) symbol(name string) (*symbol.Symbol, error) {
+	sym := b.fw.GetByName(name)
+	if sym == nil {
+		return nil, &symbol.SymbolError{ECU: b.ecu, Name: name, Err: symbol.ErrSymbolNotFound}
+	}
+	return sym, nil
+}
+
+func printJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// table prints tab-separated lines aligned in columns.
+func table(lines ...string) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, l := range lines {
+		fmt.Fprintln(w, l)
+	}
+	return w.Flush()
+}
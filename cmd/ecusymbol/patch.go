@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+
+	symbol "github.com/roffe/ecusymbol"
+)
+
+func runPatch(args []string) error {
+	o := newFlags("patch", true)
+	if verb := o.verb(args); verb != "apply" {
+		return fmt.Errorf("patch %s: want apply", verb)
+	}
+	args = o.args(args[1:], 2, false)
+	b, err := load(args[0], o.verbose)
+	if err != nil {
+		return err
+	}
+	out, err := o.output(b.path)
+	if err != nil {
+		return err
+	}
+	p, err := symbol.ReadTuningPackageFile(args[1])
+	if err != nil {
+		return err
+	}
+	if err := p.Apply(b.fw); err != nil {
+		return err
+	}
+	if !o.json {
+		fmt.Printf("applied %d operations from %s\n", len(p.Operations), args[1])
+	}
+	if err := b.fw.Save(out); err != nil {
+		return err
+	}
+	return saved(o, out)
+}
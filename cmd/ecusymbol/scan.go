@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+
+	symbol "github.com/roffe/ecusymbol"
+)
+
+// runScan reports on every .bin under a directory. With -o the report is
+// appended to as files are loaded, so an interrupted scan picks up where it
+// stopped when run again with -resume.
+func runScan(args []string) error {
+	o := newFlags("scan", false)
+	workers := o.Int("workers", 0, "load `n` files at once (default GOMAXPROCS)")
+	report := o.String("o", "", "write the report to `file`, CSV if it ends in .csv, JSON lines otherwise")
+	resume := o.Bool("resume", false, "skip files unchanged since the JSON report given with -o")
+	asCSV := o.Bool("csv", false, "print CSV")
+	args = o.args(args, 1, false)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	csvReport := strings.EqualFold(filepath.Ext(*report), ".csv")
+	opts := []symbol.ScanOpt{}
+	if *workers > 0 {
+		opts = append(opts, symbol.WithScanWorkers(*workers))
+	}
+
+	var partial *os.File
+	if *report != "" && !csvReport {
+		flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+		if *resume {
+			previous, err := readReport(*report)
+			if err != nil {
+				return err
+			}
+			opts = append(opts, symbol.WithScanPrevious(previous))
+			flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+		}
+		f, err := os.OpenFile(*report, flags, 0o644)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		partial = f
+		opts = append(opts, symbol.WithScanProgress(func(r symbol.ScanResult) {
+			symbol.WriteScanJSON(f, r)
+		}))
+	} else if *resume {
+		return errors.New("-resume needs a JSON report given with -o")
+	}
+
+	results, scanErr := symbol.Scan(ctx, args[0], opts...)
+	if partial != nil {
+		partial.Close()
+		// The appended report has stale and duplicate entries; replace it
+		// with the sorted result.
+		if err := writeFile(*report, func(f *os.File) error { return symbol.WriteScanJSON(f, results...) }); err != nil {
+			return err
+		}
+	}
+	if csvReport {
+		if err := writeFile(*report, func(f *os.File) error { return symbol.WriteScanCSV(f, results) }); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case *asCSV:
+		if err := symbol.WriteScanCSV(os.Stdout, results); err != nil {
+			return err
+		}
+	case o.json:
+		if err := symbol.WriteScanJSON(os.Stdout, results...); err != nil {
+			return err
+		}
+	case *report == "":
+		lines := []string{"path\tecu\tversion\tchecksum\tsymbols\tnames\terror"}
+		for _, r := range results {
+			checksum := "-"
+			if len(r.Checksums) > 0 {
+				checksum = map[bool]string{true: "ok", false: "BAD"}[r.ChecksumOK()]
+			}
+			lines = append(lines, fmt.Sprintf("%s\t%s\t%s\t%s\t%d\t%s\t%s", r.Path, r.ECU, r.Version, checksum, r.Symbols, r.Names, r.Error))
+		}
+		if err := table(lines...); err != nil {
+			return err
+		}
+	default:
+		fmt.Printf("%d files, report in %s\n", len(results), *report)
+	}
+	return scanErr
+}
+
+func readReport(file string) ([]symbol.ScanResult, error) {
+	f, err := os.Open(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return symbol.ReadScanJSON(f)
+}
+
+// writeFile replaces file with what write produces, or leaves it alone when
+// that fails.
+func writeFile(file string, write func(*os.File) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(file), filepath.Base(file)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	symbol "github.com/roffe/ecusymbol"
+)
+
+func runStock(args []string) error {
+	o := newFlags("stock", true)
+	dir := o.String("lib", "stock", "stock library `dir`")
+	verb := o.verb(args)
+	args = o.args(args[1:], 1, true)
+	lib, err := symbol.OpenStockLibrary(*dir)
+	if err != nil {
+		return err
+	}
+	switch verb {
+	case "add":
+		for _, path := range args {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			fp, err := lib.Add(data)
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			fmt.Printf("added %s as %s\n", path, fp.Name)
+		}
+		return nil
+	case "revert":
+	default:
+		return fmt.Errorf("stock %s: want add or revert", verb)
+	}
+
+	b, err := load(args[0], o.verbose)
+	if err != nil {
+		return err
+	}
+	out, err := o.output(b.path)
+	if err != nil {
+		return err
+	}
+	stock, err := lib.StockFor(b.fw)
+	if err != nil {
+		return err
+	}
+	var reverted []string
+	if names := args[1:]; len(names) > 0 {
+		for _, name := range names {
+			if err := stock.RevertSymbol(name); err != nil {
+				return err
+			}
+		}
+		reverted = names
+	} else if reverted, err = stock.RevertAll(nil); err != nil {
+		return err
+	}
+	if err := b.fw.Save(out); err != nil {
+		return err
+	}
+	if o.json {
+		return printJSON(map[string]any{"stock": stock.Match.Entry.Name, "reverted": reverted, "saved": out})
+	}
+	fmt.Printf("stock %s (%.1f%% of code), reverted %s\n", stock.Match.Entry.Name, 100*stock.Match.Similarity, orNone(strings.Join(reverted, ", ")))
+	return saved(o, out)
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	symbol "github.com/roffe/ecusymbol"
+)
+
+type symbolOutput struct {
+	Number  int     `json:"number"`
+	Name    string  `json:"name"`
+	Address uint32  `json:"address"`
+	Length  uint16  `json:"length"`
+	Type    uint8   `json:"type"`
+	Factor  float64 `json:"factor"`
+	Unit    string  `json:"unit,omitempty"`
+}
+
+func runSymbols(args []string) error {
+	o := newFlags("symbols", false)
+	query := o.String("match", "", "only symbols named `query`, or containing it (case-insensitive)")
+	args = o.args(args, 1, false)
+	b, err := load(args[0], o.verbose)
+	if err != nil {
+		return err
+	}
+	out := []symbolOutput{}
+	for _, s := range b.fw.Symbols() {
+		if !match(s.Name, *query) {
+			continue
+		}
+		out = append(out, symbolOutput{s.Number, s.Name, s.Address, s.Length, s.Type, s.Correctionfactor, s.Unit})
+	}
+	if o.json {
+		return printJSON(out)
+	}
+	lines := []string{"#\tname\taddress\tlength\ttype\tfactor\tunit"}
+	for _, s := range out {
+		lines = append(lines, fmt.Sprintf("%d\t%s\t0x%06X\t%d\t0x%02X\t%g\t%s", s.Number, s.Name, s.Address, s.Length, s.Type, s.Factor, s.Unit))
+	}
+	lines = append(lines, fmt.Sprintf("\n%d of %d symbols", len(out), b.fw.Count()))
+	return table(lines...)
+}
+
+// match reports whether name should be listed. An empty query matches all; a
+// non-empty query matches exactly or, failing that, as a case-insensitive
+// substring.
+func match(name, query string) bool {
+	if query == "" || name == query {
+		return true
+	}
+	return strings.Contains(strings.ToLower(name), strings.ToLower(query))
+}
+
+// isMap reports whether GetFileInfo lays name out against axes.
+func (b *firmware) isMap(name string) bool {
+	ax := symbol.GetFileInfo(b.fw, name)
+	return ax.X != "" || ax.Y != ""
+}
+
+type valuesOutput struct {
+	Name   string    `json:"name"`
+	Unit   string    `json:"unit,omitempty"`
+	Values []float64 `json:"values"`
+}
+
+func runGet(args []string) error {
+	o := newFlags("get", false)
+	args = o.args(args, 2, false)
+	b, err := load(args[0], o.verbose)
+	if err != nil {
+		return err
+	}
+	name := args[1]
+	sym, err := b.symbol(name)
+	if err != nil {
+		return err
+	}
+
+	if b.isMap(name) {
+		if o.json {
+			out, err := symbol.ExportMap(b.fw, name, symbol.MapJSON)
+			if err != nil {
+				return err
+			}
+			_, err = os.Stdout.Write(append(out, '\n'))
+			return err
+		}
+		m, err := symbol.NewMap(b.fw, name)
+		if err != nil {
+			return err
+		}
+		x, y, z := sym.Correctionfactor, 1.0, sym.Correctionfactor
+		if s := b.fw.GetByName(m.XName); s != nil {
+			x = s.Correctionfactor
+		}
+		if s := b.fw.GetByName(m.YName); s != nil {
+			y = s.Correctionfactor
+		}
+		head := []string{orNone(m.YName) + ` \ ` + orNone(m.XName)}
+		for _, v := range m.X {
+			head = append(head, format(v, x))
+		}
+		lines := []string{strings.Join(head, "\t")}
+		for r, row := range m.Z {
+			cells := []string{format(m.Y[r], y)}
+			for _, v := range row {
+				cells = append(cells, format(v, z))
+			}
+			lines = append(lines, strings.Join(cells, "\t"))
+		}
+		lines = append(lines, fmt.Sprintf("\n%s [%s], %dx%d", name, orNone(m.ZUnit), m.Rows(), m.Cols()))
+		return table(lines...)
+	}
+
+	out := valuesOutput{Name: name, Unit: sym.Unit, Values: sym.Float64s()}
+	if o.json {
+		return printJSON(out)
+	}
+	cells := make([]string, len(out.Values))
+	for i, v := range out.Values {
+		cells[i] = format(v, sym.Correctionfactor)
+	}
+	fmt.Printf("%s = %s %s\n", name, strings.Join(cells, " "), sym.Unit)
+	return nil
+}
+
+// format prints v with the decimals GetPrecision gives factor, or as many as
+// it takes for a factor it does not know.
+func format(v, factor float64) string {
+	prec := symbol.GetPrecision(factor)
+	if prec == 0 && factor != float64(int64(factor)) {
+		prec = -1
+	}
+	return strconv.FormatFloat(v, 'f', prec, 64)
+}
+
+func runSet(args []string) error {
+	o := newFlags("set", true)
+	cell := o.String("cell", "", "set the single map cell `row,col`")
+	csvFile := o.String("csv", "", "import the map from a CSV `file` as written by export")
+	jsonFile := o.String("jsonfile", "", "import the map from a JSON `file` as written by export")
+	args = o.args(args, 2, true)
+	b, err := load(args[0], o.verbose)
+	if err != nil {
+		return err
+	}
+	name, values := args[1], args[2:]
+	sym, err := b.symbol(name)
+	if err != nil {
+		return err
+	}
+	out, err := o.output(b.path)
+	if err != nil {
+		return err
+	}
+
+	var saturated []symbol.Cell
+	switch {
+	case *csvFile != "" || *jsonFile != "":
+		format, file := symbol.MapCSV, *csvFile
+		if *jsonFile != "" {
+			format, file = symbol.MapJSON, *jsonFile
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if saturated, err = symbol.ImportMap(b.fw, name, format, data); err != nil {
+			return err
+		}
+	case *cell != "":
+		var row, col int
+		if _, err := fmt.Sscanf(*cell, "%d,%d", &row, &col); err != nil {
+			return fmt.Errorf("-cell %q: want row,col", *cell)
+		}
+		if len(values) != 1 {
+			return errors.New("-cell takes exactly one value")
+		}
+		v, err := strconv.ParseFloat(values[0], 64)
+		if err != nil {
+			return err
+		}
+		m, err := symbol.NewMap(b.fw, name)
+		if err != nil {
+			return err
+		}
+		if saturated, err = m.SetCell(row, col, v); err != nil {
+			return err
+		}
+	default:
+		want := len(sym.Float64s())
+		if len(values) != want {
+			return fmt.Errorf("%s holds %d values, got %d", name, want, len(values))
+		}
+		// Through the map, so that values out of range saturate rather
+		// than wrap; they are given row by row.
+		m, err := symbol.NewMap(b.fw, name)
+		if err != nil {
+			return err
+		}
+		rows := make([][]float64, m.Rows())
+		for i, s := range values {
+			v, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				return err
+			}
+			rows[i/m.Cols()] = append(rows[i/m.Cols()], v)
+		}
+		if saturated, err = m.Paste(0, 0, rows); err != nil {
+			return err
+		}
+	}
+	for _, c := range saturated {
+		fmt.Fprintf(os.Stderr, "warning: %s[%d,%d] clamped to what the data type holds\n", name, c.Row, c.Col)
+	}
+	if err := b.fw.Save(out); err != nil {
+		return err
+	}
+	return saved(o, out)
+}
@@ -13,6 +13,7 @@ type Collection struct {
 	numberMap map[int]*Symbol
 	count     int
 	mu        sync.Mutex
+	journal   *journal
 }
 
 func NewCollection(symbols ...*Symbol) *Collection {
@@ -20,8 +21,10 @@ func NewCollection(symbols ...*Symbol) *Collection {
 		symbols:   symbols,
 		nameMap:   make(map[string]*Symbol),
 		numberMap: make(map[int]*Symbol),
+		journal:   &journal{},
 	}
 	for _, s := range symbols {
+		c.adopt(s)
 		c.nameMap[s.Name] = s
 		c.numberMap[s.Number] = s
 		c.count++
@@ -29,6 +32,14 @@ func NewCollection(symbols ...*Symbol) *Collection {
 	return c
 }
 
+// adopt makes c's journal the one s records into, unless s already belongs to
+// another Collection: a symbol shared between two is journalled by the first.
+func (c *Collection) adopt(s *Symbol) {
+	if s.journal == nil {
+		s.journal = c.journal
+	}
+}
+
 // Save fails: a Collection is an in-memory symbol set (read from a live ECU or
 // built in tests), it has no backing binary to write back to.
 func (c *Collection) Save(filename string) error {
@@ -52,12 +63,32 @@ func (c *Collection) Add(symbols ...*Symbol) {
 	defer c.mu.Unlock()
 	c.symbols = append(c.symbols, symbols...)
 	for _, s := range symbols {
+		c.adopt(s)
 		c.nameMap[s.Name] = s
 		c.numberMap[s.Number] = s
 		c.count++
 	}
 }
 
+// Rename changes a symbol's name and keeps GetByName in step with it.
+// Like every method that needs both, it takes c.mu before the journal's lock.
+func (c *Collection) Rename(oldName, newName string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.nameMap[oldName]
+	if !ok {
+		return &SymbolError{ECU: ECU_UNKNOWN, Name: oldName, Err: ErrSymbolNotFound}
+	}
+	if _, taken := c.nameMap[newName]; taken {
+		return fmt.Errorf("cannot rename %s: symbol %s already exists", oldName, newName)
+	}
+	delete(c.nameMap, oldName)
+	s.Name = newName
+	c.nameMap[newName] = s
+	c.journal.rename(oldName, newName)
+	return nil
+}
+
 func (c *Collection) Symbols() []*Symbol {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -87,7 +118,7 @@ func (c *Collection) GetXYZ(xAxis, yAxis, zAxis string) ([]int, []int, []int, fl
 	// log.Printf("GetXYZ(%s, %s, %s)", xAxis, yAxis, zAxis)
 	symx, symy, symz := c.GetByName(xAxis), c.GetByName(yAxis), c.GetByName(zAxis)
 	if symz == nil {
-		return nil, nil, nil, 0, 0, 0, fmt.Errorf("%s not found", zAxis)
+		return nil, nil, nil, 0, 0, 0, &SymbolError{ECU: ECU_UNKNOWN, Name: zAxis, Err: ErrSymbolNotFound}
 	}
 
 	// Dirty workaround for non-biopower T8 bins
@@ -134,7 +165,7 @@ func (c *Collection) GetXYZ(xAxis, yAxis, zAxis string) ([]int, []int, []int, fl
 	}
 	for k, v := range checks {
 		if v == nil {
-			return nil, nil, nil, 0, 0, 0, fmt.Errorf("failed to find %s", k)
+			return nil, nil, nil, 0, 0, 0, &SymbolError{ECU: ECU_UNKNOWN, Name: k, Err: ErrSymbolNotFound}
 		}
 	}
 	return nil, nil, nil, 0, 0, 0, fmt.Errorf("failed to convert x:%s y:%s z:%s", xAxis, yAxis, zAxis)
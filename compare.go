@@ -0,0 +1,204 @@
+package symbol
+
+import (
+	"bytes"
+	"sort"
+)
+
+// Diff is what Compare finds between two firmware files, a and b.
+type Diff struct {
+	OnlyInA []string     `json:"onlyInA,omitempty"`
+	OnlyInB []string     `json:"onlyInB,omitempty"`
+	Changed []SymbolDiff `json:"changed,omitempty"`
+	Moved   []SymbolMove `json:"moved,omitempty"`
+	Raw     []ByteRange  `json:"raw,omitempty"` // differing bytes no symbol covers
+}
+
+// SymbolDiff is a symbol whose data differs. Cells holds the physical values
+// that differ when both sides have the same length, laid out as the map (or a
+// single row for anything that is not one).
+type SymbolDiff struct {
+	Name  string     `json:"name"`
+	Bytes int        `json:"bytes"` // number of differing bytes
+	Cells []CellDiff `json:"cells,omitempty"`
+}
+
+type CellDiff struct {
+	Cell
+	A     float64 `json:"a"`
+	B     float64 `json:"b"`
+	Delta float64 `json:"delta"` // B - A
+}
+
+// SymbolMove is a symbol found at another address or with another length.
+type SymbolMove struct {
+	Name     string `json:"name"`
+	AddressA uint32 `json:"addressA"`
+	AddressB uint32 `json:"addressB"`
+	LengthA  uint16 `json:"lengthA"`
+	LengthB  uint16 `json:"lengthB"`
+}
+
+// ByteRange is [Start, End) in the binary.
+type ByteRange struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// Compare diffs a against b symbol by symbol, and reports the bytes that
+// differ outside every symbol of either file (code, footer, checksums) when
+// both are backed by a binary.
+func Compare(a, b FirmwareFile) (*Diff, error) {
+	d := &Diff{}
+	ecu := ecuOf(a)
+	for _, sa := range a.Symbols() {
+		sb := b.GetByName(sa.Name)
+		if sb == nil {
+			d.OnlyInA = append(d.OnlyInA, sa.Name)
+			continue
+		}
+		if sa.Address != sb.Address || sa.Length != sb.Length {
+			d.Moved = append(d.Moved, SymbolMove{Name: sa.Name, AddressA: sa.Address, AddressB: sb.Address, LengthA: sa.Length, LengthB: sb.Length})
+		}
+		if bytes.Equal(sa.data, sb.data) {
+			continue
+		}
+		d.Changed = append(d.Changed, symbolDiff(a, sa, sb))
+	}
+	for _, sb := range b.Symbols() {
+		if a.GetByName(sb.Name) == nil {
+			d.OnlyInB = append(d.OnlyInB, sb.Name)
+		}
+	}
+	sort.Strings(d.OnlyInA)
+	sort.Strings(d.OnlyInB)
+	sort.Slice(d.Changed, func(i, j int) bool { return d.Changed[i].Name < d.Changed[j].Name })
+	sort.Slice(d.Moved, func(i, j int) bool { return d.Moved[i].Name < d.Moved[j].Name })
+
+	rawA, err := rawImage(a)
+	if err != nil {
+		return nil, err
+	}
+	rawB, err := rawImage(b)
+	if err != nil {
+		return nil, err
+	}
+	if rawA != nil && rawB != nil {
+		d.Raw = rawDiff(rawA, rawB, func(cover []bool) {
+			markSymbols(cover, ecu, a)
+			markSymbols(cover, ecuOf(b), b)
+		})
+	}
+	return d, nil
+}
+
+func symbolDiff(fw FirmwareFile, sa, sb *Symbol) SymbolDiff {
+	sd := SymbolDiff{Name: sa.Name}
+	n := min(len(sa.data), len(sb.data))
+	for i := 0; i < n; i++ {
+		if sa.data[i] != sb.data[i] {
+			sd.Bytes++
+		}
+	}
+	sd.Bytes += max(len(sa.data), len(sb.data)) - n
+	if len(sa.data) != len(sb.data) {
+		return sd
+	}
+	cols := mapCols(fw, sa)
+	va, vb := sa.Float64s(), sb.Float64s()
+	for i := range va {
+		if va[i] != vb[i] {
+			sd.Cells = append(sd.Cells, CellDiff{Cell: Cell{Row: i / cols, Col: i % cols}, A: va[i], B: vb[i], Delta: vb[i] - va[i]})
+		}
+	}
+	return sd
+}
+
+// mapCols is the row length of sym laid out as a map, or its whole length
+// when it is not one (or fw has no ECU type to look the layout up by).
+func mapCols(fw FirmwareFile, sym *Symbol) int {
+	if ax := GetFileInfo(fw, sym.Name); ax.X != "" || ax.Y != "" {
+		if m, err := NewMap(fw, sym.Name); err == nil && m.z == sym {
+			return m.Cols()
+		}
+	}
+	return max(1, len(sym.Ints()))
+}
+
+func ecuOf(fw FirmwareFile) ECUType {
+	switch fw.(type) {
+	case *T5File:
+		return ECU_T5
+	case *T7File:
+		return ECU_T7
+	case *T8File:
+		return ECU_T8
+	case *AW55File:
+		return ECU_AW55
+	}
+	return ECU_UNKNOWN
+}
+
+// rawImage is the binary behind fw, nil for a plain Collection. It is read as
+// is: writing the symbols back would also redo the checksums Compare reports.
+func rawImage(fw FirmwareFile) ([]byte, error) {
+	switch f := fw.(type) {
+	case *T5File:
+		return f.Bytes(), nil
+	case *T7File:
+		return f.Bytes(), nil
+	case *T8File:
+		return f.Bytes(), nil
+	case *AW55File:
+		return f.data, nil
+	}
+	return nil, nil
+}
+
+// markSymbols marks the flash bytes of every symbol of fw, placing SRAM
+// symbols the way Save does and AW55 curves over their whole record.
+func markSymbols(cover []bool, ecu ECUType, fw FirmwareFile) {
+	record := make(map[*Symbol]int)
+	if aw55, ok := fw.(*AW55File); ok {
+		for _, c := range aw55.curves {
+			record[c.x], record[c.y] = 2*len(c.x.data), 2*len(c.y.data)
+		}
+	}
+	for _, sym := range fw.Symbols() {
+		addr := int(sym.Address)
+		if addr == 0 {
+			continue
+		}
+		if ecu == ECU_T7 && sym.Address > 0x7FFFFF {
+			addr = int(sym.Address - sym.SramOffset)
+		}
+		n := int(sym.Length)
+		if r, ok := record[sym]; ok {
+			n = r
+		}
+		for i := addr; i < addr+n && i < len(cover); i++ {
+			if i >= 0 {
+				cover[i] = true
+			}
+		}
+	}
+}
+
+func rawDiff(a, b []byte, mark func(cover []bool)) []ByteRange {
+	n := max(len(a), len(b))
+	cover := make([]bool, n)
+	mark(cover)
+	var out []ByteRange
+	for i := 0; i < n; i++ {
+		differ := i >= len(a) || i >= len(b) || a[i] != b[i]
+		if !differ || cover[i] {
+			continue
+		}
+		if k := len(out) - 1; k >= 0 && out[k].End == i {
+			out[k].End++
+		} else {
+			out = append(out, ByteRange{Start: i, End: i + 1})
+		}
+	}
+	return out
+}
@@ -0,0 +1,111 @@
+package symbol
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	a := NewCollection(
+		testSymbol("Pwm_ind_trot!", CHAR, 1, 0, 50, 100),
+		testSymbol("Pwm_ind_rpm!", 0, 10, 100, 200),
+		testSymbol("Tryck_mat!", CHAR, 0.01, 100, 150, 200, 120, 170, 220),
+		testSymbol("only-a", 0, 1, 1),
+	)
+	b := NewCollection(
+		testSymbol("Pwm_ind_trot!", CHAR, 1, 0, 50, 100),
+		testSymbol("Pwm_ind_rpm!", 0, 10, 100, 200),
+		testSymbol("Tryck_mat!", CHAR, 0.01, 100, 150, 200, 120, 180, 220),
+		testSymbol("only-b", 0, 1, 1),
+	)
+	b.GetByName("Pwm_ind_rpm!").Address = 0x1234
+
+	d, err := Compare(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(d.OnlyInA, []string{"only-a"}) || !slices.Equal(d.OnlyInB, []string{"only-b"}) {
+		t.Fatalf("only in a %v, only in b %v", d.OnlyInA, d.OnlyInB)
+	}
+	if len(d.Moved) != 1 || d.Moved[0].Name != "Pwm_ind_rpm!" || d.Moved[0].AddressB != 0x1234 {
+		t.Fatalf("moved %+v", d.Moved)
+	}
+	if len(d.Changed) != 1 || d.Changed[0].Bytes != 1 || len(d.Changed[0].Cells) != 1 {
+		t.Fatalf("changed %+v", d.Changed)
+	}
+	// A plain Collection has no ECU type to find the map shape by, so the
+	// cell is addressed as in a single row.
+	if c := d.Changed[0].Cells[0]; c.Cell != (Cell{0, 4}) || c.Delta < 0.099 || c.Delta > 0.101 {
+		t.Fatalf("cell %+v", c)
+	}
+	if d.Raw != nil {
+		t.Fatalf("raw ranges for in-memory collections: %v", d.Raw)
+	}
+}
+
+func TestRawDiff(t *testing.T) {
+	a := []byte{0, 1, 2, 3, 4, 5, 6, 7}
+	b := []byte{0, 9, 9, 3, 9, 5, 6, 7, 8}
+	got := rawDiff(a, b, func(cover []bool) { cover[4] = true })
+	want := []ByteRange{{1, 3}, {8, 9}}
+	if !slices.Equal(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+// Comparing reads a T5 image as it is, without writing the edits or the
+// checksum into it.
+func TestCompareT5Untouched(t *testing.T) {
+	file := func() *T5File {
+		sym := testSymbol("Tryck_mat!", CHAR, 0.01, 100, 150)
+		sym.Address = 0x100
+		return &T5File{Collection: NewCollection(sym), data: make([]byte, LengthT55), printFunc: func(string) {}}
+	}
+	a, b := file(), file()
+	b.GetByName("Tryck_mat!").SetData([]byte{1, 2})
+	d, err := Compare(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Changed) != 1 || len(d.Raw) != 0 {
+		t.Fatalf("changed %+v, raw %v", d.Changed, d.Raw)
+	}
+	if slices.ContainsFunc(b.Bytes(), func(v byte) bool { return v != 0 }) {
+		t.Fatal("Compare wrote into the T5 image")
+	}
+}
+
+// An AW55 curve's x and y values interleave in one record, all of which the
+// curve symbols cover.
+func TestCompareAW55Curve(t *testing.T) {
+	a, err := NewAW55File(aw55TestImage(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edited, err := NewAW55File(aw55TestImage(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	y := edited.GetByName("Curve-7E000")
+	y.SetData(y.EncodeInts([]int{100, 400})) // the second point, at 0x7E006
+	image, err := edited.(*AW55File).Byte()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewAW55File(image, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := Compare(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range d.Raw {
+		if r.Start < 0x7E010 && r.End > 0x7E000 {
+			t.Errorf("curve record reported as raw %+v", r)
+		}
+	}
+	if len(d.Changed) != 1 || d.Changed[0].Name != "Curve-7E000" {
+		t.Fatalf("changed %+v", d.Changed)
+	}
+}
@@ -0,0 +1,256 @@
+package symbol
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Definition is an external definition (TunerPro XDF, ECUFlash or RomRaider
+// XML) read as a symbol source, for binaries that carry no symbol table of
+// their own. Symbols has one symbol per constant and table, without data; Axes
+// links each table to the symbols that are its axes, in the form GetInfo
+// reports.
+//
+// The offset of a linear expression goes into the symbol's Offset.
+type Definition struct {
+	Title   string
+	Symbols []*Symbol
+	Axes    AxisInformation
+	// Skipped lists what a Symbol cannot describe: little-endian or floating
+	// point data, non-linear equations, tables without an address.
+	Skipped []string
+}
+
+// Collection reads the definition's symbols out of image, for binaries no
+// loader in this package understands.
+func (d *Definition) Collection(image []byte) (*Collection, error) {
+	symbols, err := d.read(image)
+	if err != nil {
+		return nil, err
+	}
+	return NewCollection(symbols...), nil
+}
+
+// exportable reports whether a symbol of fw can be described by an address
+// in a definition of the size byte binary: not without an address, nor past
+// its end, nor a T7 symbol that only lives in SRAM (Address > 0x7FFFFF), nor an
+// AW55 record-format curve, which is de-interleaved on load.
+func exportable(fw FirmwareFile, size int) func(*Symbol) bool {
+	ecu := ecuOf(fw)
+	interleaved := make(map[*Symbol]bool)
+	if aw55, ok := fw.(*AW55File); ok {
+		for _, c := range aw55.curves {
+			interleaved[c.x], interleaved[c.y] = true, true
+		}
+	}
+	return func(s *Symbol) bool {
+		switch {
+		case s == nil || s.Address == 0 || interleaved[s]:
+			return false
+		case ecu == ECU_T7 && s.Address > 0x7FFFFF:
+			return false
+		case size > 0 && int(s.Address)+int(s.Length) > size:
+			return false
+		}
+		return true
+	}
+}
+
+// WithT5Definition adds the symbols of def that the binary's own symbol table
+// lacks, and their axes to GetFileInfo for this file. A binary without a
+// symbol table loads from def alone.
+func WithT5Definition(def *Definition) T5FileOpt {
+	return func(t5 *T5File) error {
+		t5.definition = def
+		return nil
+	}
+}
+
+// WithAW55Definition adds the symbols of def to those of the calibration
+// family, and loads a binary of an unknown family from def alone.
+func WithAW55Definition(def *Definition) AW55FileOpt {
+	return func(aw55 *AW55File) error {
+		aw55.definition = def
+		return nil
+	}
+}
+
+// extra reads the symbols of d that c does not have yet, numbered on from its
+// own. One with the name of a symbol of c is left out, and so is one whose
+// bytes overlap those of a symbol already there: written back after it, the
+// definition's stale copy would undo its edits. alias maps the name of each
+// one left out for overlapping to the symbol it overlaps.
+func (d *Definition) extra(c *Collection, image []byte) (out []*Symbol, alias map[string]string, err error) {
+	symbols, err := d.read(image)
+	if err != nil {
+		return nil, nil, err
+	}
+	have := slices.Clone(c.Symbols())
+	alias = make(map[string]string)
+	for _, s := range symbols {
+		if c.GetByName(s.Name) != nil {
+			continue
+		}
+		if o := overlapping(have, s); o != nil {
+			alias[s.Name] = o.Name
+			continue
+		}
+		s.Number = c.Count() + len(out)
+		out = append(out, s)
+		have = append(have, s)
+	}
+	return out, alias, nil
+}
+
+// aliased is name, or the symbol extra used in its place.
+func aliased(alias map[string]string, name string) string {
+	if a, ok := alias[name]; ok {
+		return a
+	}
+	return name
+}
+
+// overlapping is the first of symbols whose bytes in flash overlap those of s.
+func overlapping(symbols []*Symbol, s *Symbol) *Symbol {
+	if s.Address == 0 || s.Length == 0 {
+		return nil
+	}
+	for _, o := range symbols {
+		if o.Address != 0 && o.Address < s.Address+uint32(s.Length) && s.Address < o.Address+uint32(o.Length) {
+			return o
+		}
+	}
+	return nil
+}
+
+// read gives fresh copies of the symbols with their data from image.
+func (d *Definition) read(image []byte) ([]*Symbol, error) {
+	out := make([]*Symbol, 0, len(d.Symbols))
+	for _, s := range d.Symbols {
+		end := int(s.Address) + int(s.Length)
+		if end > len(image) {
+			return nil, &SymbolError{ECU: ECU_UNKNOWN, Name: s.Name, Address: s.Address, Err: ErrAddressOutOfRange}
+		}
+		c := *s
+		c.journal = nil
+		c.data = append([]byte(nil), image[s.Address:end]...)
+		out = append(out, &c)
+	}
+	return out, nil
+}
+
+// linearEquation reduces an expression in X (TunerPro) or x (ECUFlash) to
+// factor*X+offset, refusing anything that is not linear.
+func linearEquation(eq string) (factor, offset float64, err error) {
+	eq = strings.TrimSpace(eq)
+	if eq == "" {
+		return 1, 0, nil
+	}
+	at := func(x float64) (float64, error) {
+		p := &exprParser{in: strings.ReplaceAll(eq, " ", ""), x: x}
+		v, err := p.sum()
+		if err == nil && p.pos != len(p.in) {
+			err = fmt.Errorf("equation %q: unexpected %q", eq, p.in[p.pos:])
+		}
+		return v, err
+	}
+	f0, err := at(0)
+	if err != nil {
+		return 0, 0, err
+	}
+	f1, err := at(1)
+	if err != nil {
+		return 0, 0, err
+	}
+	f7, err := at(7)
+	if err != nil {
+		return 0, 0, err
+	}
+	// Equations are written in decimal, so rounding to 12 digits takes off
+	// what f1-f0 loses to binary floating point.
+	factor, offset = roundDecimal(f1-f0), roundDecimal(f0)
+	if math.IsInf(f0+f1+f7, 0) || math.IsNaN(f0+f1+f7) || math.Abs(f7-(7*factor+offset)) > 1e-9*math.Max(1, math.Abs(f7)) {
+		return 0, 0, fmt.Errorf("equation %q is not linear", eq)
+	}
+	return factor, offset, nil
+}
+
+func roundDecimal(v float64) float64 {
+	r, err := strconv.ParseFloat(strconv.FormatFloat(v, 'g', 12, 64), 64)
+	if err != nil {
+		return v
+	}
+	return r
+}
+
+// exprParser evaluates + - * / and parentheses over numbers and X.
+type exprParser struct {
+	in  string
+	pos int
+	x   float64
+}
+
+func (p *exprParser) sum() (float64, error) {
+	v, err := p.product()
+	for err == nil && p.pos < len(p.in) && (p.in[p.pos] == '+' || p.in[p.pos] == '-') {
+		op := p.in[p.pos]
+		p.pos++
+		var r float64
+		if r, err = p.product(); op == '+' {
+			v += r
+		} else {
+			v -= r
+		}
+	}
+	return v, err
+}
+
+func (p *exprParser) product() (float64, error) {
+	v, err := p.unary()
+	for err == nil && p.pos < len(p.in) && (p.in[p.pos] == '*' || p.in[p.pos] == '/') {
+		op := p.in[p.pos]
+		p.pos++
+		var r float64
+		if r, err = p.unary(); op == '*' {
+			v *= r
+		} else {
+			v /= r
+		}
+	}
+	return v, err
+}
+
+func (p *exprParser) unary() (float64, error) {
+	if p.pos < len(p.in) && p.in[p.pos] == '-' {
+		p.pos++
+		v, err := p.unary()
+		return -v, err
+	}
+	if p.pos >= len(p.in) {
+		return 0, fmt.Errorf("equation %q ends early", p.in)
+	}
+	switch c := p.in[p.pos]; {
+	case c == '(':
+		p.pos++
+		v, err := p.sum()
+		if err != nil {
+			return 0, err
+		}
+		if p.pos >= len(p.in) || p.in[p.pos] != ')' {
+			return 0, fmt.Errorf("equation %q: missing )", p.in)
+		}
+		p.pos++
+		return v, nil
+	case c == 'X' || c == 'x':
+		p.pos++
+		return p.x, nil
+	}
+	start := p.pos
+	for p.pos < len(p.in) && (p.in[p.pos] >= '0' && p.in[p.pos] <= '9' || p.in[p.pos] == '.' || p.in[p.pos] == 'e' || p.in[p.pos] == 'E') {
+		p.pos++
+	}
+	return strconv.ParseFloat(p.in[start:p.pos], 64)
+}
@@ -1,9 +1,6 @@
 package symbol
 
-import (
-	"bytes"
-	"fmt"
-)
+import "bytes"
 
 const (
 	MaxFileLength    = 0x200000
@@ -31,7 +28,7 @@ func DetectType(data []byte) (ECUType, error) {
 	}
 
 	// Unknown file type
-	return ECU_UNKNOWN, fmt.Errorf("unknown file type")
+	return ECU_UNKNOWN, ErrUnknownFileType
 }
 
 func IsTrionic5File(data []byte) error {
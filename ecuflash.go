@@ -0,0 +1,370 @@
+package symbol
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ECUFlash and RomRaider XML definitions. ECUFlash keeps the scalings at the
+// top of the rom and has tables refer to them by name; RomRaider wraps the rom
+// in <roms>, puts the address and sizes under other attribute names and inlines
+// the scaling in each table. Export writes the ECUFlash flavour, import reads
+// both.
+
+type ecuFlashROMs struct {
+	ROMs []ecuFlashROM `xml:"rom"`
+}
+
+type ecuFlashROM struct {
+	XMLName  xml.Name
+	ROMID    ecuFlashROMID     `xml:"romid"`
+	Include  []string          `xml:"include,omitempty"`
+	Scalings []ecuFlashScaling `xml:"scaling"`
+	Tables   []ecuFlashTable   `xml:"table"`
+}
+
+type ecuFlashROMID struct {
+	XMLID             string `xml:"xmlid"`
+	InternalIDAddress string `xml:"internalidaddress"`
+	InternalIDString  string `xml:"internalidstring"`
+	Make              string `xml:"make,omitempty"`
+	Model             string `xml:"model,omitempty"`
+	MemModel          string `xml:"memmodel,omitempty"`
+	FileSize          string `xml:"filesize,omitempty"`
+}
+
+type ecuFlashScaling struct {
+	Name        string `xml:"name,attr,omitempty"`
+	Units       string `xml:"units,attr,omitempty"`
+	ToExpr      string `xml:"toexpr,attr,omitempty"`
+	FrExpr      string `xml:"frexpr,attr,omitempty"`
+	Expression  string `xml:"expression,attr,omitempty"` // RomRaider's toexpr
+	ToByte      string `xml:"to_byte,attr,omitempty"`    // RomRaider's frexpr
+	Format      string `xml:"format,attr,omitempty"`
+	StorageType string `xml:"storagetype,attr,omitempty"`
+	Endian      string `xml:"endian,attr,omitempty"`
+}
+
+type ecuFlashTable struct {
+	Name     string `xml:"name,attr"`
+	Type     string `xml:"type,attr,omitempty"`
+	Category string `xml:"category,attr,omitempty"`
+	Address  string `xml:"address,attr,omitempty"`
+	Elements int    `xml:"elements,attr,omitempty"`
+	Scaling  string `xml:"scaling,attr,omitempty"`
+
+	StorageAddress string `xml:"storageaddress,attr,omitempty"`
+	StorageType    string `xml:"storagetype,attr,omitempty"`
+	Endian         string `xml:"endian,attr,omitempty"`
+	SizeX          int    `xml:"sizex,attr,omitempty"`
+	SizeY          int    `xml:"sizey,attr,omitempty"`
+	Size           int    `xml:"size,attr,omitempty"`
+
+	Inline *ecuFlashScaling `xml:"scaling,omitempty"`
+	Axes   []ecuFlashTable  `xml:"table"`
+}
+
+// ecuFlashStorage is the storage type of a symbol, following Ints.
+func ecuFlashStorage(s *Symbol) string {
+	t := fmt.Sprintf("int%d", 8*s.elementSize())
+	if s.Type&SIGNED != SIGNED {
+		t = "u" + t
+	}
+	return t
+}
+
+// ecuFlashExprs are the to and from expressions of raw*factor+offset.
+func ecuFlashExprs(factor, offset float64) (to, from string) {
+	f := strconv.FormatFloat(factor, 'g', -1, 64)
+	o := strconv.FormatFloat(offset, 'g', -1, 64)
+	switch {
+	case offset == 0:
+		return "x*" + f, "x/" + f
+	case offset > 0:
+		return "x*" + f + "+" + o, "(x-" + o + ")/" + f
+	}
+	return "x*" + f + o, "(x+" + o[1:] + ")/" + f
+}
+
+var ecuFlashMemModel = map[ECUType]string{
+	ECU_T5:   "68332",
+	ECU_T7:   "68332",
+	ECU_T8:   "68377",
+	ECU_AW55: "SH7058",
+}
+
+// ExportECUFlash writes an ECUFlash definition of every flash symbol of fw,
+// with its maps laid out the way axes describes them (GetFileAxisCollection
+// when nil). The romid carries Version() as the
+// internal ID, and its address when the version string is found in the binary,
+// for T7 byte-reversed as the footer stores it.
+func ExportECUFlash(fw FirmwareFile, axes AxisInformation) ([]byte, error) {
+	ecu := ecuOf(fw)
+	if axes == nil {
+		axes = GetFileAxisCollection(fw)
+	}
+	raw, _ := rawImage(fw)
+	exported := exportable(fw, len(raw))
+
+	rom := ecuFlashROM{
+		XMLName: xml.Name{Local: "rom"},
+		ROMID: ecuFlashROMID{
+			XMLID:            fw.Version(),
+			InternalIDString: fw.Version(),
+			Make:             "Saab",
+			Model:            ecu.String(),
+			MemModel:         ecuFlashMemModel[ecu],
+		},
+	}
+	if len(raw) > 0 {
+		rom.ROMID.FileSize = fmt.Sprintf("%dkb", len(raw)/1024)
+		if v := fw.Version(); v != "" {
+			id := []byte(v)
+			i := bytes.Index(raw, id)
+			if i < 0 && ecu == ECU_T7 {
+				// The T7 footer keeps the version byte-reversed, and the
+				// ID string has to be what ECUFlash finds at the address.
+				slices.Reverse(id)
+				if i = bytes.Index(raw, id); i >= 0 {
+					rom.ROMID.InternalIDString = string(id)
+				}
+			}
+			if i >= 0 {
+				rom.ROMID.InternalIDAddress = fmt.Sprintf("%x", i)
+			}
+		}
+	}
+
+	scaled := make(map[string]bool)
+	scaling := func(s *Symbol) string {
+		if !scaled[s.Name] {
+			scaled[s.Name] = true
+			to, from := ecuFlashExprs(s.Correctionfactor, s.offset())
+			rom.Scalings = append(rom.Scalings, ecuFlashScaling{
+				Name: s.Name, Units: s.Unit, ToExpr: to, FrExpr: from,
+				Format: "%.2f", StorageType: ecuFlashStorage(s), Endian: "big",
+			})
+		}
+		return s.Name
+	}
+	count := func(s *Symbol) int { return int(s.Length) / s.elementSize() }
+	axis := func(s *Symbol, typ string) ecuFlashTable {
+		return ecuFlashTable{Name: s.Name, Type: typ, Address: fmt.Sprintf("%x", s.Address), Elements: count(s), Scaling: scaling(s)}
+	}
+
+	for _, s := range fw.Symbols() {
+		if !exported(s) {
+			continue
+		}
+		t := ecuFlashTable{Name: s.Name, Type: "1D", Category: ecu.String(), Address: fmt.Sprintf("%x", s.Address), Scaling: scaling(s)}
+		info := axes[s.Name]
+		x, y := fw.GetByName(info.X), fw.GetByName(info.Y)
+		switch n := count(s); {
+		case n <= 1:
+		case exported(x) && exported(y) && count(x)*count(y) == n:
+			t.Type = "3D"
+			t.Axes = []ecuFlashTable{axis(x, "X Axis"), axis(y, "Y Axis")}
+		case exported(x) && count(x) == n:
+			t.Type = "2D"
+			t.Axes = []ecuFlashTable{axis(x, "Y Axis")}
+		default:
+			// Values without an axis: ECUFlash shows them as a 2D table
+			// against the element index.
+			t.Type = "2D"
+			t.Elements = n
+		}
+		rom.Tables = append(rom.Tables, t)
+	}
+
+	out, err := xml.MarshalIndent(rom, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), out...), nil
+}
+
+func LoadECUFlash(filename string) (*Definition, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return ParseECUFlash(data)
+}
+
+// ParseECUFlash reads an ECUFlash or RomRaider definition. Tables that only
+// exist in an <include>d base definition, static axes and data Symbol cannot
+// hold end up in Skipped.
+func ParseECUFlash(data []byte) (*Definition, error) {
+	var rom ecuFlashROM
+	if err := xml.Unmarshal(data, &rom); err != nil {
+		return nil, err
+	}
+	if rom.XMLName.Local == "roms" {
+		var roms ecuFlashROMs
+		if err := xml.Unmarshal(data, &roms); err != nil {
+			return nil, err
+		}
+		if len(roms.ROMs) == 0 {
+			return nil, fmt.Errorf("no rom in definition")
+		}
+		rom = roms.ROMs[0]
+	}
+	def := &Definition{Title: rom.ROMID.XMLID, Axes: make(AxisInformation)}
+	for _, inc := range rom.Include {
+		def.Skipped = append(def.Skipped, "include "+inc+": not resolved")
+	}
+	scalings := make(map[string]ecuFlashScaling, len(rom.Scalings))
+	for _, sc := range rom.Scalings {
+		scalings[sc.Name] = sc
+	}
+
+	// symbol builds the symbol for count values of t, or says why it
+	// cannot.
+	symbol := func(name string, t ecuFlashTable, count int) (*Symbol, string) {
+		addr := t.Address
+		if addr == "" {
+			addr = t.StorageAddress
+		}
+		if addr == "" {
+			return nil, "no address"
+		}
+		a, err := strconv.ParseUint(strings.TrimPrefix(strings.ToLower(addr), "0x"), 16, 32)
+		if err != nil {
+			return nil, err.Error()
+		}
+		sc := scalings[t.Scaling]
+		if t.Inline != nil {
+			sc = *t.Inline
+		}
+		storage, endian := sc.StorageType, sc.Endian
+		if t.StorageType != "" {
+			storage = t.StorageType
+		}
+		if t.Endian != "" {
+			endian = t.Endian
+		}
+		if e := strings.ToLower(endian); e == "little" || e == "lsb" {
+			return nil, "little-endian data"
+		}
+		expr := sc.ToExpr
+		if expr == "" {
+			expr = sc.Expression
+		}
+		factor, offset, err := linearEquation(expr)
+		if err != nil {
+			return nil, err.Error()
+		}
+		s := &Symbol{
+			Name:             name,
+			Number:           len(def.Symbols),
+			Address:          uint32(a),
+			Correctionfactor: factor,
+			Unit:             sc.Units,
+		}
+		width := 0
+		switch strings.ToLower(storage) {
+		case "int8":
+			s.Type, width = SIGNED|CHAR, 1
+		case "uint8":
+			s.Type, width = CHAR, 1
+		case "int16":
+			s.Type, width = SIGNED, 2
+		case "uint16":
+			width = 2
+		case "int32":
+			s.Type, width = SIGNED|LONG, 4
+		case "uint32":
+			s.Type, width = LONG, 4
+		default:
+			return nil, fmt.Sprintf("storage type %q", storage)
+		}
+		s.Length = uint16(max(count, 1) * width)
+		s.Offset = offset
+		return s, ""
+	}
+	skip := func(name, why string) {
+		def.Skipped = append(def.Skipped, name+": "+why)
+	}
+	// axisSymbol gives the name of an axis' symbol, sharing it when another
+	// table already defined the same axis.
+	axisSymbol := func(table string, a ecuFlashTable) (string, int) {
+		n := a.Elements
+		if n == 0 {
+			n = a.Size
+		}
+		if strings.HasPrefix(strings.ToLower(a.Type), "static") || n == 0 {
+			return "", n
+		}
+		name := a.Name
+		s, why := symbol(name, a, n)
+		if s == nil {
+			skip(table+" "+a.Name, why)
+			return "", n
+		}
+		for _, have := range def.Symbols {
+			if have.Name != name {
+				continue
+			}
+			if have.Address == s.Address && have.Length == s.Length {
+				return name, n
+			}
+			name = table + " " + a.Name
+			s.Name = name
+		}
+		def.Symbols = append(def.Symbols, s)
+		return name, n
+	}
+
+	for _, t := range rom.Tables {
+		info := Axis{Z: t.Name}
+		var xs, ys ecuFlashTable
+		cols, rows := max(t.Elements, t.SizeX, t.Size), max(t.SizeY, 1)
+		for _, a := range t.Axes {
+			switch strings.ToLower(a.Type) {
+			case "x axis", "static x axis":
+				xs = a
+			case "y axis", "static y axis":
+				ys = a
+			}
+		}
+		switch strings.ToUpper(t.Type) {
+		case "3D":
+			var n int
+			if info.X, n = axisSymbol(t.Name, xs); n > 0 {
+				cols = n
+			}
+			if info.Y, n = axisSymbol(t.Name, ys); n > 0 {
+				rows = n
+			}
+			info.XDescription, info.YDescription = xs.Name, ys.Name
+		case "2D":
+			// The one axis of a 2D table is its X, whichever way it is drawn.
+			a := ys
+			if a.Name == "" {
+				a = xs
+			}
+			if name, n := axisSymbol(t.Name, a); n > 0 {
+				info.X, cols = name, n
+				info.XDescription = a.Name
+			}
+		}
+		s, why := symbol(t.Name, t, cols*rows)
+		if s == nil {
+			skip(t.Name, why)
+			continue
+		}
+		def.Symbols = append(def.Symbols, s)
+		if info.X != "" || info.Y != "" {
+			def.Axes[t.Name] = info
+		}
+	}
+	for i, s := range def.Symbols {
+		s.Number = i
+	}
+	return def, nil
+}
@@ -0,0 +1,109 @@
+package symbol
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestECUFlashRoundTrip(t *testing.T) {
+	fw := &T7File{Collection: NewCollection(
+		testSymbolAt("BFuelCal.AirXSP", 0x1000, 0, 1, 100, 200, 300),
+		testSymbolAt("BFuelCal.RpmYSP", 0x1010, 0, 1, 1000, 2000),
+		testSymbolAt("BFuelCal.Map", 0x1020, SIGNED, 0.01, 1, 2, 3, 4, 5, -6),
+		testSymbolAt("IgnProt.fi_Offset", 0x1040, SIGNED|CHAR, 0.1, -5),
+		testSymbolAt("ActualIn.n_Engine", 0xF00100, 0, 1, 800),
+	)}
+	fw.GetByName("IgnProt.fi_Offset").Offset = -40
+	axes := AxisInformation{"BFuelCal.Map": {X: "BFuelCal.AirXSP", Y: "BFuelCal.RpmYSP", Z: "BFuelCal.Map"}}
+	out, err := ExportECUFlash(fw, axes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), `type="3D"`) || strings.Contains(string(out), "ActualIn.n_Engine") {
+		t.Fatalf("export:\n%s", out)
+	}
+	def, err := ParseECUFlash(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(def.Skipped) != 0 {
+		t.Fatalf("skipped %v", def.Skipped)
+	}
+	if ax := def.Axes["BFuelCal.Map"]; ax.X != "BFuelCal.AirXSP" || ax.Y != "BFuelCal.RpmYSP" {
+		t.Fatalf("axes %+v", ax)
+	}
+	if _, leaked := T5Offsets["IgnProt.fi_Offset"]; leaked {
+		t.Fatal("import wrote T5Offsets")
+	}
+
+	image := make([]byte, 0x2000)
+	for _, s := range fw.Symbols()[:4] {
+		copy(image[s.Address:], s.Bytes())
+	}
+	c, err := def.Collection(image)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Count() != 4 {
+		t.Fatalf("%d symbols, want 4", c.Count())
+	}
+	for _, want := range fw.Symbols()[:4] {
+		got := c.GetByName(want.Name)
+		if got == nil || got.Address != want.Address || got.Length != want.Length || got.Type != want.Type || got.Correctionfactor != want.Correctionfactor {
+			t.Fatalf("%s: got %+v, want %+v", want.Name, got, want)
+		}
+		if !slices.Equal(got.Float64s(), want.Float64s()) {
+			t.Errorf("%s: %v, want %v", want.Name, got.Float64s(), want.Float64s())
+		}
+	}
+}
+
+// T7 keeps its version byte-reversed in the footer, which is where the
+// internal ID is found.
+func TestECUFlashT7InternalID(t *testing.T) {
+	data := make([]byte, 0x80000)
+	copy(data[0x7FF00:], "O10F90UE")
+	fw := &T7File{Collection: NewCollection(), data: data, softwareVersion: "EU09F01O"}
+	out, err := ExportECUFlash(fw, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), "<internalidaddress>7ff00</internalidaddress>") || !strings.Contains(string(out), "<internalidstring>O10F90UE</internalidstring>") {
+		t.Fatalf("romid:\n%s", out)
+	}
+}
+
+func TestParseRomRaider(t *testing.T) {
+	def, err := ParseECUFlash([]byte(`<roms><rom>
+  <romid><xmlid>TEST01</xmlid><internalidaddress>100</internalidaddress><internalidstring>TEST01</internalidstring></romid>
+  <table type="2D" name="Idle Target" storageaddress="0x200" storagetype="uint8" endian="big">
+    <scaling units="rpm" expression="x*25" to_byte="x/25" format="0"/>
+    <table type="Y Axis" name="Coolant" storageaddress="0x210" storagetype="int8" size="4">
+      <scaling units="C" expression="x-40" to_byte="x+40"/>
+    </table>
+  </table>
+  <table type="1D" name="Rev Limit" storageaddress="0x220" storagetype="uint16" endian="little">
+    <scaling expression="x"/>
+  </table>
+</rom></roms>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if def.Title != "TEST01" || len(def.Symbols) != 2 || len(def.Skipped) != 1 {
+		t.Fatalf("definition %+v", def)
+	}
+	s := def.Symbols[1]
+	if s.Name != "Idle Target" || s.Address != 0x200 || s.Length != 4 || s.Type != CHAR || s.Correctionfactor != 25 {
+		t.Fatalf("table %+v", s)
+	}
+	if ax := def.Axes["Idle Target"]; ax.X != "Coolant" {
+		t.Fatalf("axes %+v", ax)
+	}
+	if c := def.Symbols[0]; c.Name != "Coolant" || c.Offset != -40 {
+		t.Fatalf("axis %+v, offset %g", c, c.Offset)
+	}
+	if _, leaked := T5Offsets["Coolant"]; leaked {
+		t.Fatal("import wrote T5Offsets")
+	}
+}
@@ -1,9 +1,13 @@
 package symbol
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	ErrChecksumMismatch           = errors.New("checksum mismatch")
+	ErrChecksumAreaNotFound       = errors.New("checksum area not found")
 	ErrSymbolTableNotFound        = errors.New("no symbol table found")
 	ErrInvalidSymbolTableHeader   = errors.New("invalid symbol table header")
 	ErrEndOfSymbolTableNotFound   = errors.New("end of symbol table not found")
@@ -14,7 +18,102 @@ var (
 	ErrOffsetOutOfRange           = errors.New("offset out of range")
 	ErrDataIsEmpty                = errors.New("data is empty")
 	ErrVersionNotFound            = errors.New("version not found")
+	ErrHeaderNotFound             = errors.New("header field not found")
 	ErrAddressOutOfRange          = errors.New("address out of range")
 	ErrInvalidFile                = errors.New("invalid file")
 	ErrNotFileBacked              = errors.New("symbols are not backed by a file")
+	ErrUnknownFileType            = errors.New("unknown file type")
+	ErrUnknownFamily              = errors.New("unknown calibration family")
+	ErrSymbolNotFound             = errors.New("symbol not found")
+	ErrDataLength                 = errors.New("data has incorrect length")
+	ErrShiftSchedule              = errors.New("shift schedule invariant violated")
+	ErrNothingToUndo              = errors.New("nothing to undo")
+	ErrNothingToRedo              = errors.New("nothing to redo")
+	ErrUnknownFormat              = errors.New("unknown format")
+	ErrMapShape                   = errors.New("map shape differs")
+	ErrAxisMismatch               = errors.New("axis breakpoints differ")
+	ErrAxisOrder                  = errors.New("axis breakpoints out of order")
+	ErrStockNotFound              = errors.New("no matching stock file")
+	ErrZeroFactor                 = errors.New("correction factor is zero")
 )
+
+// The typed errors below carry enough context to sort failures across a large
+// batch of bins without matching on messages. Each one still satisfies
+// errors.Is against the sentinel it stands for. ECU is ECU_UNKNOWN where the
+// failing code does not know which ECU it is working on.
+
+// ChecksumError is a stored checksum that does not match the calculated one.
+type ChecksumError struct {
+	ECU      ECUType
+	Area     string // "FW", "L1", "L2", "ROM", ... as in LoadEvent
+	Offset   int    // file offset of the stored checksum, -1 if not known
+	Expected []byte // calculated
+	Actual   []byte // stored in the file
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("%s%s checksum mismatch%s: expected %X, got %X", ecuPrefix(e.ECU), e.Area, atOffset(e.Offset), e.Expected, e.Actual)
+}
+
+func (e *ChecksumError) Is(target error) bool {
+	return target == ErrChecksumMismatch
+}
+
+// SymbolError is a failure tied to one symbol. Err is the sentinel saying what
+// went wrong (ErrSymbolNotFound, ErrDataLength, ErrAddressOutOfRange, ...).
+// Expected and Actual are only set for length errors.
+type SymbolError struct {
+	ECU      ECUType
+	Name     string
+	Address  uint32
+	Expected int
+	Actual   int
+	Err      error
+}
+
+func (e *SymbolError) Error() string {
+	switch {
+	case errors.Is(e.Err, ErrDataLength):
+		return fmt.Sprintf("%ssymbol %s expected %d bytes, got %d", ecuPrefix(e.ECU), e.Name, e.Expected, e.Actual)
+	case e.Address != 0:
+		return fmt.Sprintf("%ssymbol %s @%08X: %v", ecuPrefix(e.ECU), e.Name, e.Address, e.Err)
+	default:
+		return fmt.Sprintf("%ssymbol %s: %v", ecuPrefix(e.ECU), e.Name, e.Err)
+	}
+}
+
+func (e *SymbolError) Unwrap() error {
+	return e.Err
+}
+
+// OffsetError is a read or write outside the data, or a structure that could
+// not be found at the offset the format says it lives at.
+type OffsetError struct {
+	ECU    ECUType
+	What   string // what was being read, e.g. "symbol table address"
+	Offset int
+	Length int // length of the data that was indexed
+	Err    error
+}
+
+func (e *OffsetError) Error() string {
+	return fmt.Sprintf("%s%s%s (data length 0x%X): %v", ecuPrefix(e.ECU), e.What, atOffset(e.Offset), e.Length, e.Err)
+}
+
+func (e *OffsetError) Unwrap() error {
+	return e.Err
+}
+
+func ecuPrefix(ecu ECUType) string {
+	if ecu == ECU_UNKNOWN {
+		return ""
+	}
+	return ecu.String() + " "
+}
+
+func atOffset(offset int) string {
+	if offset < 0 {
+		return ""
+	}
+	return fmt.Sprintf(" at 0x%X", offset)
+}
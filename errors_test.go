@@ -0,0 +1,52 @@
+package symbol
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// Batch tooling sorts failures with errors.Is/As, so the typed errors must
+// match their sentinels and keep their context through wrapping.
+func TestTypedErrors(t *testing.T) {
+	s := &Symbol{Name: "BFuelCal.Map", Address: 0x743A, Length: 4}
+	err := s.SetData([]byte{1, 2})
+	if !errors.Is(err, ErrDataLength) {
+		t.Fatalf("SetData: %v is not ErrDataLength", err)
+	}
+	var se *SymbolError
+	if !errors.As(err, &se) || se.Name != s.Name || se.Expected != 4 || se.Actual != 2 {
+		t.Fatalf("SetData: got %#v", err)
+	}
+
+	var cerr error = &ChecksumError{ECU: ECU_T5, Area: "ROM", Offset: 0x3FFFC, Expected: be32(1), Actual: be32(2)}
+	if !errors.Is(cerr, ErrChecksumMismatch) {
+		t.Fatalf("%v is not ErrChecksumMismatch", cerr)
+	}
+	if got, want := cerr.Error(), "T5 ROM checksum mismatch at 0x3FFFC: expected 00000001, got 00000002"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+
+	_, err = GetAddressFromOffset(make([]byte, 8), 6)
+	var oe *OffsetError
+	if !errors.Is(err, ErrOffsetOutOfRange) || !errors.As(err, &oe) || oe.Offset != 6 {
+		t.Fatalf("GetAddressFromOffset: got %#v", err)
+	}
+
+	_, err = GetT7HeaderField(make([]byte, 0x200), 0x95)
+	if !errors.Is(err, ErrHeaderNotFound) || !errors.As(err, &oe) || oe.ECU != ECU_T7 {
+		t.Fatalf("GetT7HeaderField: got %#v", err)
+	}
+	// Shared by T7 and T8, so no ECU prefix in the message.
+	_, err = ExpandCompressedSymbolNames(make([]byte, 16))
+	if !errors.Is(err, ErrInvalidSymbolTableHeader) || !strings.HasPrefix(err.Error(), "compressed symbol names") {
+		t.Fatalf("ExpandCompressedSymbolNames: got %#v", err)
+	}
+	t8 := &T8File{data: make([]byte, 0x100)}
+	if _, err := t8.GetChecksumAreaOffset(t8.data); !errors.Is(err, ErrOffsetOutOfRange) {
+		t.Fatalf("GetChecksumAreaOffset: got %#v", err)
+	}
+	if err := t8.UpdateLayer2(0xF0, 0, 0x10); !errors.Is(err, ErrOffsetOutOfRange) || !errors.As(err, &oe) || oe.Offset != 0x101 {
+		t.Fatalf("UpdateLayer2: got %#v", err)
+	}
+}
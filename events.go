@@ -0,0 +1,79 @@
+package symbol
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// LoadEventKind says what a LoadEvent reports. The values are stable strings so
+// they survive a trip through JSON to a UI unchanged.
+type LoadEventKind string
+
+const (
+	EventStageStarted  LoadEventKind = "stage-started"
+	EventStageFinished LoadEventKind = "stage-finished"
+	EventChecksum      LoadEventKind = "checksum"      // one verdict per checksum area
+	EventFooterRepair  LoadEventKind = "footer-repair" // T7 PI area rebuilt on load
+	EventSymbolCount   LoadEventKind = "symbol-count"
+	EventNameSource    LoadEventKind = "name-source" // where the symbol names came from
+	EventWarning       LoadEventKind = "warning"
+)
+
+// LoadStage names a step of loading a binary.
+type LoadStage string
+
+const (
+	StageDetect   LoadStage = "detect"
+	StageSymbols  LoadStage = "symbols"
+	StageHeaders  LoadStage = "headers"
+	StageChecksum LoadStage = "checksum"
+)
+
+// NameSource is how the symbol names of a binary were recovered.
+type NameSource string
+
+const (
+	NamesPlain      NameSource = "plain"      // uncompressed name list (T5, older T7)
+	NamesLZHUF      NameSource = "lzhuf"      // lzhuf packed name table
+	NamesBlowfish   NameSource = "blowfish"   // blowfish encrypted name table
+	NamesXML        NameSource = "xml"        // embedded T7Suite XML, picked by version
+	NamesDefinition NameSource = "definition" // external definition (AW55 has no names at all)
+)
+
+// LoadEvent is the machine readable counterpart of the printFunc messages.
+// Only the fields relevant to Kind are set.
+type LoadEvent struct {
+	Kind LoadEventKind `json:"kind"`
+	ECU  ECUType       `json:"ecu"`
+
+	Stage LoadStage `json:"stage,omitempty"`
+
+	// EventChecksum. Area is the checksum the ECU type calls it by: "FW",
+	// "F2" and "FB" on T7, "L1" and "L2" on T8, "ROM" on T5. Expected is what
+	// was calculated, Actual what the file holds.
+	Area     string `json:"area,omitempty"`
+	Valid    bool   `json:"valid"`           // always present, a failed verdict most of all
+	Fixed    bool   `json:"fixed,omitempty"` // corrected in memory, not yet saved
+	Expected []byte `json:"expected,omitempty"`
+	Actual   []byte `json:"actual,omitempty"`
+
+	Count  int        `json:"count,omitempty"`  // EventSymbolCount
+	Source NameSource `json:"source,omitempty"` // EventNameSource
+
+	Message string `json:"message,omitempty"` // EventWarning, EventFooterRepair; the XML version for NamesXML
+}
+
+// compressedNameSource tells the two packed name table formats apart the same
+// way ExpandCompressedSymbolNames does.
+func compressedNameSource(in []byte) NameSource {
+	if bytes.HasPrefix(in, blowfishNameMagic) {
+		return NamesBlowfish
+	}
+	return NamesLZHUF
+}
+
+func be32(v uint32) []byte {
+	return binary.BigEndian.AppendUint32(nil, v)
+}
+
+func noEvent(LoadEvent) {}
@@ -0,0 +1,56 @@
+package symbol
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// A footer without immobilizer and chassis id is rebuilt on load when
+// autoFixFooter is set, and the UI needs to hear about it without parsing logs.
+func TestFooterRepairEvent(t *testing.T) {
+	var got []LoadEvent
+	t7 := &T7File{
+		data:          make([]byte, T7Length),
+		autoFixFooter: true,
+		eventFunc:     func(e LoadEvent) { got = append(got, e) },
+	}
+	applyRealBinPiArea(t7)
+	t7.Collection = NewCollection()
+	t7.loadHeaders()
+
+	if len(got) != 1 {
+		t.Fatalf("got %d events, want 1: %+v", len(got), got)
+	}
+	if e := got[0]; e.Kind != EventFooterRepair || e.ECU != ECU_T7 || e.Message != "immobilizer id missing" {
+		t.Fatalf("got %+v", e)
+	}
+
+	// The rebuilt footer carries both ids, so a second pass has nothing to fix.
+	got = nil
+	t7.chassisIDCounter = 0
+	t7.loadHeaders()
+	if len(got) != 0 {
+		t.Fatalf("footer repaired twice: %+v", got)
+	}
+}
+
+func TestCompressedNameSource(t *testing.T) {
+	if got := compressedNameSource(append(blowfishNameMagic[:len(blowfishNameMagic):len(blowfishNameMagic)], 0x00)); got != NamesBlowfish {
+		t.Errorf("blowfish table detected as %s", got)
+	}
+	if got := compressedNameSource([]byte{0x10, 0x27, 0x00, 0x00}); got != NamesLZHUF {
+		t.Errorf("lzhuf table detected as %s", got)
+	}
+}
+
+// A failed checksum verdict is what a consumer of the JSON most needs to see.
+func TestLoadEventValidJSON(t *testing.T) {
+	out, err := json.Marshal(LoadEvent{Kind: EventChecksum, Area: "ROM"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), `"valid":false`) {
+		t.Fatalf("failed verdict missing: %s", out)
+	}
+}
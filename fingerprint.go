@@ -0,0 +1,196 @@
+package symbol
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"sort"
+)
+
+// A Fingerprint identifies the code of a binary, not its version string,
+// which tuners edit. The image is cut into fingerprintBlock sized blocks with
+// every symbol's bytes (and the whole AW55 calibration) blanked out, and each
+// block hashed: two files built from the same code share all blocks but the
+// few holding checksums, footer and version string. The calibration is kept
+// as one hash per symbol, enough to tell which symbols a file changed.
+type Fingerprint struct {
+	Name        string            `json:"name"` // what it was added as, usually the stock file name
+	ECU         string            `json:"ecu"`
+	Version     string            `json:"version"`
+	Size        int               `json:"size"`
+	Blocks      []string          `json:"blocks"`      // "" for a block with nothing but blanked or erased bytes
+	Calibration map[string]string `json:"calibration"` // symbol name to hash of its data
+}
+
+const fingerprintBlock = 0x1000
+
+func fingerprintHash(b []byte) string {
+	h := fnv.New64a()
+	h.Write(b)
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+// NewFingerprint fingerprints fw, which has to be backed by a binary.
+func NewFingerprint(name string, fw FirmwareFile) (*Fingerprint, error) {
+	raw, err := rawImage(fw)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) == 0 {
+		return nil, ErrNotFileBacked
+	}
+	ecu := ecuOf(fw)
+	image := bytes.Clone(raw)
+	if ecu == ECU_AW55 {
+		// The upper half is blank, a mirror or foreign; the code is below.
+		image = image[:min(len(image), aw55Length)]
+	}
+	cover := make([]bool, len(image))
+	markSymbols(cover, ecu, fw)
+	if ecu == ECU_AW55 && len(image) >= aw55CalEnd {
+		for i := aw55CalBase; i < aw55CalEnd; i++ {
+			cover[i] = true
+		}
+	}
+	for i, c := range cover {
+		if c {
+			image[i] = 0xFF
+		}
+	}
+
+	fp := &Fingerprint{Name: name, ECU: ecu.String(), Version: fw.Version(), Size: len(raw), Calibration: make(map[string]string)}
+	for start := 0; start < len(image); start += fingerprintBlock {
+		block := image[start:min(start+fingerprintBlock, len(image))]
+		if isBlank(block) {
+			fp.Blocks = append(fp.Blocks, "")
+			continue
+		}
+		fp.Blocks = append(fp.Blocks, fingerprintHash(block))
+	}
+	for _, s := range fw.Symbols() {
+		if s.Address != 0 && len(s.data) > 0 {
+			fp.Calibration[s.Name] = fingerprintHash(s.data)
+		}
+	}
+	return fp, nil
+}
+
+func isBlank(b []byte) bool {
+	return bytes.Count(b, []byte{0xFF}) == len(b) || bytes.Count(b, []byte{0x00}) == len(b)
+}
+
+// FingerprintMatch is how closely a file matches a database entry.
+type FingerprintMatch struct {
+	Entry *Fingerprint `json:"entry"`
+	// Similarity is the share of code blocks the two have in common, blocks
+	// blank in both not counted. Anything built from the same code lands
+	// close to 1.
+	Similarity float64 `json:"similarity"`
+	CodeBlocks int     `json:"codeBlocks"`
+	Differing  int     `json:"differing"`
+	// Changed lists the symbols whose data differs from the entry's, sorted.
+	// Compared is how many symbols both have.
+	Changed  []string `json:"changed,omitempty"`
+	Compared int      `json:"compared"`
+}
+
+// FingerprintDB is a local collection of stock fingerprints, saved as JSON.
+type FingerprintDB struct {
+	Entries []*Fingerprint `json:"entries"`
+}
+
+// LoadFingerprintDB reads a database written by Save; a file that does not
+// exist yet is an empty database.
+func LoadFingerprintDB(filename string) (*FingerprintDB, error) {
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return &FingerprintDB{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var db FingerprintDB
+	if err := json.Unmarshal(data, &db); err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return &db, nil
+}
+
+func (db *FingerprintDB) Save(filename string) error {
+	data, err := json.MarshalIndent(db, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, data, 0o644)
+}
+
+// Add stores fp, replacing an entry of the same name.
+func (db *FingerprintDB) Add(fp *Fingerprint) {
+	for i, e := range db.Entries {
+		if e.Name == fp.Name {
+			db.Entries[i] = fp
+			return
+		}
+	}
+	db.Entries = append(db.Entries, fp)
+}
+
+// Lookup compares fp with every entry of the same ECU type and size, best
+// match first.
+func (db *FingerprintDB) Lookup(fp *Fingerprint) []FingerprintMatch {
+	var out []FingerprintMatch
+	for _, e := range db.Entries {
+		if e.ECU != fp.ECU || e.Size != fp.Size || len(e.Blocks) != len(fp.Blocks) {
+			continue
+		}
+		m := FingerprintMatch{Entry: e}
+		for i := range e.Blocks {
+			if e.Blocks[i] == "" && fp.Blocks[i] == "" {
+				continue
+			}
+			m.CodeBlocks++
+			if e.Blocks[i] != fp.Blocks[i] {
+				m.Differing++
+			}
+		}
+		if m.CodeBlocks > 0 {
+			m.Similarity = float64(m.CodeBlocks-m.Differing) / float64(m.CodeBlocks)
+		}
+		for name, h := range fp.Calibration {
+			stock, ok := e.Calibration[name]
+			if !ok {
+				continue
+			}
+			m.Compared++
+			if stock != h {
+				m.Changed = append(m.Changed, name)
+			}
+		}
+		sort.Strings(m.Changed)
+		out = append(out, m)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Similarity != out[j].Similarity {
+			return out[i].Similarity > out[j].Similarity
+		}
+		return len(out[i].Changed) < len(out[j].Changed)
+	})
+	return out
+}
+
+// Identify fingerprints fw and returns the best match, or nil when no entry
+// shares at least minSimilarity of its code.
+func (db *FingerprintDB) Identify(fw FirmwareFile, minSimilarity float64) (*FingerprintMatch, error) {
+	fp, err := NewFingerprint("", fw)
+	if err != nil {
+		return nil, err
+	}
+	matches := db.Lookup(fp)
+	if len(matches) == 0 || matches[0].Similarity < minSimilarity {
+		return nil, nil
+	}
+	return &matches[0], nil
+}
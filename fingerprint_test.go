@@ -0,0 +1,73 @@
+package symbol
+
+import (
+	"math/rand"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestFingerprint(t *testing.T) {
+	withCode := func(seed int64) []byte {
+		data := aw55TestImage()
+		rand.New(rand.NewSource(seed)).Read(data[aw55BootEnd:0x20000])
+		return data
+	}
+	fingerprint := func(name string, data []byte) (FirmwareFile, *Fingerprint) {
+		fw, err := NewAW55File(data, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fp, err := NewFingerprint(name, fw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return fw, fp
+	}
+
+	db := &FingerprintDB{}
+	_, stock := fingerprint("stock.bin", withCode(1))
+	_, other := fingerprint("other.bin", withCode(2))
+	db.Add(stock)
+	db.Add(other)
+
+	// A tune: a curve changed and a byte of the code region edited, as a
+	// patched version string would be.
+	tuned := withCode(1)
+	tuned[0x9000] ^= 0xFF
+	fw, _ := fingerprint("", tuned)
+	y := fw.GetByName("Curve-7E000")
+	if err := y.SetData(y.EncodeInts([]int{150, 300})); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "fingerprints.json")
+	if err := db.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	db, err := LoadFingerprintDB(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := db.Identify(fw, 0.9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m == nil || m.Entry.Name != "stock.bin" {
+		t.Fatalf("identified %+v, want stock.bin", m)
+	}
+	if m.CodeBlocks != 25 || m.Differing != 1 {
+		t.Errorf("%d of %d code blocks differ, want 1 of 25 (the code plus the family pointer)", m.Differing, m.CodeBlocks)
+	}
+	if !slices.Equal(m.Changed, []string{"Curve-7E000"}) || m.Compared != len(stock.Calibration) {
+		t.Errorf("changed %v of %d", m.Changed, m.Compared)
+	}
+
+	_, fp := fingerprint("", tuned)
+	if matches := db.Lookup(fp); len(matches) != 2 || matches[1].Similarity > 0.1 {
+		t.Errorf("other code matched: %+v", matches)
+	}
+	if empty, err := LoadFingerprintDB(filepath.Join(t.TempDir(), "none.json")); err != nil || len(empty.Entries) != 0 {
+		t.Errorf("missing database: %v, %v", empty, err)
+	}
+}
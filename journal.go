@@ -0,0 +1,208 @@
+package symbol
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Change is one SetData on a symbol of a Collection. Changes made between two
+// Checkpoints share a Step and are undone and redone together; before the
+// first Checkpoint every change is a step of its own.
+type Change struct {
+	Step   int       `json:"step"`
+	Time   time.Time `json:"time"`
+	Symbol string    `json:"symbol"`
+	Label  string    `json:"label,omitempty"`
+	Old    []byte    `json:"old"`
+	New    []byte    `json:"new"`
+}
+
+// journal records what SetData does to the symbols of one Collection. Loaders
+// fill symbols without SetData, so it starts out empty. Where the Collection's
+// mu is needed as well, it is taken first.
+type journal struct {
+	mu      sync.Mutex
+	applied []Change
+	undone  []Change // most recently undone last
+	step    int
+	grouped bool
+	label   string
+}
+
+type journalState struct {
+	Changes []Change `json:"changes"`
+	Undone  []Change `json:"undone,omitempty"`
+	Step    int      `json:"step"`
+	Grouped bool     `json:"grouped,omitempty"`
+	Label   string   `json:"label,omitempty"`
+}
+
+func (j *journal) record(name string, old, data []byte) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if !j.grouped {
+		j.step++
+	}
+	j.applied = append(j.applied, Change{
+		Step:   j.step,
+		Time:   time.Now(),
+		Symbol: name,
+		Label:  j.label,
+		Old:    bytes.Clone(old),
+		New:    bytes.Clone(data),
+	})
+	j.undone = nil
+}
+
+// Checkpoint starts a new undo step. Changes until the next Checkpoint carry
+// label and are undone as one.
+func (c *Collection) Checkpoint(label string) {
+	c.journal.mu.Lock()
+	defer c.journal.mu.Unlock()
+	c.journal.step++
+	c.journal.grouped = true
+	c.journal.label = label
+}
+
+// Changes lists the changes since load that have not been undone, oldest first.
+func (c *Collection) Changes() []Change {
+	c.journal.mu.Lock()
+	defer c.journal.mu.Unlock()
+	return slices.Clone(c.journal.applied)
+}
+
+// Undo reverts the most recent step and returns its changes.
+func (c *Collection) Undo() ([]Change, error) {
+	return c.move(true)
+}
+
+// Redo reapplies the most recently undone step.
+func (c *Collection) Redo() ([]Change, error) {
+	return c.move(false)
+}
+
+// move takes the last step off one stack, writes it back, and pushes it onto
+// the other. Both stacks keep a step's changes in the order they were made;
+// undo writes them back last to first, redo first to last.
+func (c *Collection) move(undo bool) ([]Change, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	j := c.journal
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	from, to, empty := &j.applied, &j.undone, ErrNothingToUndo
+	if !undo {
+		from, to, empty = &j.undone, &j.applied, ErrNothingToRedo
+	}
+	if len(*from) == 0 {
+		return nil, empty
+	}
+	n := len(*from) - 1
+	for n > 0 && (*from)[n-1].Step == (*from)[len(*from)-1].Step {
+		n--
+	}
+	step := slices.Clone((*from)[n:])
+	syms := make([]*Symbol, len(step))
+	for i, ch := range step {
+		if syms[i] = c.nameMap[ch.Symbol]; syms[i] == nil {
+			return nil, &SymbolError{ECU: ECU_UNKNOWN, Name: ch.Symbol, Err: ErrSymbolNotFound}
+		}
+	}
+	// Written directly rather than through SetData, which would record them.
+	if undo {
+		for i := len(step) - 1; i >= 0; i-- {
+			syms[i].data = bytes.Clone(step[i].Old)
+		}
+	} else {
+		for i, ch := range step {
+			syms[i].data = bytes.Clone(ch.New)
+		}
+	}
+	*from = (*from)[:n]
+	*to = append(*to, step...)
+	return step, nil
+}
+
+// MarshalJournal serialises the changes since load, and those undone that can
+// still be redone, to be replayed onto a fresh load of the same file with
+// ResumeJournal.
+func (c *Collection) MarshalJournal() ([]byte, error) {
+	j := c.journal
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return json.MarshalIndent(journalState{Changes: j.applied, Undone: j.undone, Step: j.step, Grouped: j.grouped, Label: j.label}, "", " ")
+}
+
+// ResumeJournal replays a journal saved with MarshalJournal. Every change must
+// find its symbol holding the bytes it replaced, so a journal only resumes on
+// the file it was recorded on; nothing is written if one does not. The redo
+// stack comes back too, checked the same way against the replayed state.
+func (c *Collection) ResumeJournal(data []byte) error {
+	var st journalState
+	if err := json.Unmarshal(data, &st); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	current := make(map[string][]byte)
+	replay := func(ch Change) error {
+		sym := c.nameMap[ch.Symbol]
+		if sym == nil {
+			return &SymbolError{ECU: ECU_UNKNOWN, Name: ch.Symbol, Err: ErrSymbolNotFound}
+		}
+		have, ok := current[ch.Symbol]
+		if !ok {
+			have = sym.data
+		}
+		if !bytes.Equal(have, ch.Old) {
+			return fmt.Errorf("journal step %d does not apply: %s differs from what it replaced", ch.Step, ch.Symbol)
+		}
+		current[ch.Symbol] = ch.New
+		return nil
+	}
+	for _, ch := range st.Changes {
+		if err := replay(ch); err != nil {
+			return err
+		}
+	}
+	resumed := maps.Clone(current)
+	// Redo takes the undone steps last first, each in the order it was made.
+	for end := len(st.Undone); end > 0; {
+		n := end - 1
+		for n > 0 && st.Undone[n-1].Step == st.Undone[end-1].Step {
+			n--
+		}
+		for _, ch := range st.Undone[n:end] {
+			if err := replay(ch); err != nil {
+				return err
+			}
+		}
+		end = n
+	}
+	j := c.journal
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for name, b := range resumed {
+		c.nameMap[name].data = bytes.Clone(b)
+	}
+	j.applied, j.undone = st.Changes, st.Undone
+	j.step, j.grouped, j.label = st.Step, st.Grouped, st.Label
+	return nil
+}
+
+func (j *journal) rename(oldName, newName string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for _, changes := range [][]Change{j.applied, j.undone} {
+		for i := range changes {
+			if changes[i].Symbol == oldName {
+				changes[i].Symbol = newName
+			}
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package symbol
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestJournal(t *testing.T) {
+	newFile := func() *Collection {
+		return NewCollection(
+			testSymbol("a", 0, 1, 1, 2),
+			testSymbol("b", 0, 1, 3, 4),
+		)
+	}
+	c := newFile()
+	a, b := c.GetByName("a"), c.GetByName("b")
+	ints := func(s *Symbol) []int { return s.Ints() }
+
+	a.SetData(a.EncodeInts([]int{10, 20}))
+	c.Checkpoint("boost")
+	a.SetData(a.EncodeInts([]int{11, 21}))
+	b.SetData(b.EncodeInts([]int{30, 40}))
+	if n := len(c.Changes()); n != 3 {
+		t.Fatalf("%d changes, want 3", n)
+	}
+	if ch := c.Changes()[2]; ch.Label != "boost" || ch.Symbol != "b" || ch.Time.IsZero() {
+		t.Fatalf("change %+v", ch)
+	}
+
+	saved, err := c.MarshalJournal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The checkpointed step goes as one.
+	undone, err := c.Undo()
+	if err != nil || len(undone) != 2 {
+		t.Fatalf("undo: %v, %v", undone, err)
+	}
+	if ints(a)[0] != 10 || ints(b)[0] != 3 {
+		t.Fatalf("after undo a=%v b=%v", ints(a), ints(b))
+	}
+	if _, err := c.Undo(); err != nil || ints(a)[0] != 1 {
+		t.Fatalf("second undo: %v, a=%v", err, ints(a))
+	}
+	if _, err := c.Undo(); !errors.Is(err, ErrNothingToUndo) {
+		t.Fatalf("undo past load: %v", err)
+	}
+	c.Redo()
+	c.Redo()
+	if ints(a)[0] != 11 || ints(b)[0] != 30 {
+		t.Fatalf("after redo a=%v b=%v", ints(a), ints(b))
+	}
+	if _, err := c.Redo(); !errors.Is(err, ErrNothingToRedo) {
+		t.Fatalf("redo past the end: %v", err)
+	}
+
+	// A renamed symbol can still be undone.
+	c.Rename("b", "boost")
+	if _, err := c.Undo(); err != nil || ints(b)[0] != 3 {
+		t.Fatalf("undo after rename: %v, b=%v", err, ints(b))
+	}
+
+	// Resume on a fresh load of the same file.
+	fresh := newFile()
+	if err := fresh.ResumeJournal(saved); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(fresh.GetByName("b").Bytes(), []byte{0, 30, 0, 40}) || len(fresh.Changes()) != 3 {
+		t.Fatalf("resumed b=%v, %d changes", fresh.GetByName("b").Ints(), len(fresh.Changes()))
+	}
+	if _, err := fresh.Undo(); err != nil || fresh.GetByName("a").Ints()[0] != 10 {
+		t.Fatalf("undo after resume: %v", err)
+	}
+	if err := fresh.ResumeJournal(saved); err == nil {
+		t.Fatal("journal resumed on a file it does not match")
+	}
+
+	// What was undone can still be redone after a round trip.
+	saved, err = fresh.MarshalJournal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	again := newFile()
+	if err := again.ResumeJournal(saved); err != nil {
+		t.Fatal(err)
+	}
+	if got := again.GetByName("a").Ints()[0]; got != 10 {
+		t.Fatalf("resumed a=%d, want 10", got)
+	}
+	if _, err := again.Redo(); err != nil || again.GetByName("b").Ints()[0] != 30 {
+		t.Fatalf("redo after resume: %v, b=%v", err, again.GetByName("b").Ints())
+	}
+
+	// A symbol added to a second Collection stays in the first one's journal.
+	other := NewCollection(a)
+	a.SetData(a.EncodeInts([]int{12, 22}))
+	if len(other.Changes()) != 0 {
+		t.Fatalf("second collection journalled %v", other.Changes())
+	}
+	if _, err := c.Undo(); err != nil || ints(a)[0] != 10 {
+		t.Fatalf("undo in the first collection: %v, a=%v", err, ints(a))
+	}
+}
+
+// Undo and Rename take the collection's and the journal's locks in the same
+// order, so running them together cannot deadlock.
+func TestJournalLockOrder(t *testing.T) {
+	c := NewCollection(testSymbol("a", 0, 1, 1), testSymbol("b", 0, 1, 2))
+	a := c.GetByName("a")
+	for i := range 100 {
+		a.SetData(a.EncodeInts([]int{i}))
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 100 {
+			c.Undo()
+		}
+	}()
+	for range 100 {
+		c.Rename("b", "c")
+		c.Rename("c", "b")
+	}
+	<-done
+}
@@ -0,0 +1,167 @@
+package symbol
+
+import "fmt"
+
+// Map is a calibration map in physical units, assembled from its Z symbol and
+// the axes GetFileInfo names for it. Z is indexed Z[y][x], the way the ECUs
+// store them: one row per Y breakpoint. A curve has a single row (or a single
+// column when it has no axis symbol at all, like Batt_korr_tab!).
+type Map struct {
+	Name string
+	X, Y []float64
+	Z    [][]float64
+
+	XName, YName string // axis symbols, empty for an implicit axis
+	XFrom, YFrom string // the live values that drive the axes, where known
+
+	XUnit, YUnit, ZUnit                      string
+	XDescription, YDescription, ZDescription string
+
+	ecu        ECUType
+	x, y, z    *Symbol
+	xfac, yfac float64
+}
+
+// NewMap builds the map called name from fw. An axis the layout does not name
+// is implicit and runs 0, 1, 2, … over the cells; one it names that fw does
+// not have is an error.
+func NewMap(fw FirmwareFile, name string) (*Map, error) {
+	ecu := ecuOf(fw)
+	ax := GetFileInfo(fw, name)
+	z := fw.GetByName(ax.Z)
+	if z == nil {
+		return nil, &SymbolError{ECU: ecu, Name: ax.Z, Err: ErrSymbolNotFound}
+	}
+	axisSymbol := func(name string) (*Symbol, error) {
+		if name == "" {
+			return nil, nil
+		}
+		if sym := fw.GetByName(name); sym != nil {
+			return sym, nil
+		}
+		return nil, &SymbolError{ECU: ecu, Name: name, Err: ErrSymbolNotFound}
+	}
+	x, err := axisSymbol(ax.X)
+	if err != nil {
+		return nil, err
+	}
+	y, err := axisSymbol(ax.Y)
+	if err != nil {
+		return nil, err
+	}
+
+	zi := z.Ints()
+	xi, yi := implicitAxis(1), implicitAxis(len(zi))
+	xfac, yfac := 1.0, 1.0
+	if x != nil {
+		xi, xfac = x.Ints(), x.Correctionfactor
+		yi = implicitAxis(len(zi) / max(len(xi), 1))
+	}
+	if y != nil {
+		yi, yfac = y.Ints(), y.Correctionfactor
+		if x == nil {
+			xi = implicitAxis(len(zi) / max(len(yi), 1))
+		}
+	}
+	// An empty map would leave Lookup nothing to index.
+	if len(xi)*len(yi) != len(zi) || len(zi) == 0 {
+		return nil, &SymbolError{ECU: ecu, Name: name, Expected: max(len(xi)*len(yi), 1), Actual: len(zi), Err: ErrDataLength}
+	}
+	m := &Map{
+		Name:         name,
+		XFrom:        ax.XFrom,
+		YFrom:        ax.YFrom,
+		XDescription: ax.XDescription,
+		YDescription: ax.YDescription,
+		ZDescription: ax.ZDescription,
+		ecu:          ecu,
+		x:            x,
+		y:            y,
+		z:            z,
+		xfac:         xfac,
+		yfac:         yfac,
+	}
+	if x != nil {
+		m.XName, m.XUnit = x.Name, x.Unit
+	}
+	if y != nil {
+		m.YName, m.YUnit = y.Name, y.Unit
+	}
+	m.ZUnit = z.Unit
+
+	m.X = physical(xi, xfac, x.offset())
+	m.Y = physical(yi, yfac, y.offset())
+	zs := physical(zi, z.Correctionfactor, z.offset())
+	for r := range m.Y {
+		m.Z = append(m.Z, zs[r*len(m.X):(r+1)*len(m.X)])
+	}
+	return m, nil
+}
+
+// implicitAxis is the breakpoints of an axis with no symbol behind it.
+func implicitAxis(n int) []int {
+	out := make([]int, n)
+	for i := range out {
+		out[i] = i
+	}
+	return out
+}
+
+func physical(raw []int, factor, offset float64) []float64 {
+	out := make([]float64, len(raw))
+	for i, v := range raw {
+		out[i] = float64(v)*factor + offset
+	}
+	return out
+}
+
+// Rows and Cols are the Y and X dimensions.
+func (m *Map) Rows() int { return len(m.Y) }
+func (m *Map) Cols() int { return len(m.X) }
+
+func (m *Map) String() string {
+	return fmt.Sprintf("%s %dx%d", m.Name, m.Rows(), m.Cols())
+}
+
+// Lookup interpolates Z bilinearly at (x, y). Inputs outside an axis are
+// clamped to its end breakpoint, as the ECU does, so there is no
+// extrapolation. Axes may run either way.
+func (m *Map) Lookup(x, y float64) float64 {
+	c, fx := axisPosition(m.X, x)
+	r, fy := axisPosition(m.Y, y)
+	c1, r1 := min(c+1, len(m.X)-1), min(r+1, len(m.Y)-1)
+	top := m.Z[r][c] + (m.Z[r][c1]-m.Z[r][c])*fx
+	bottom := m.Z[r1][c] + (m.Z[r1][c1]-m.Z[r1][c])*fx
+	return top + (bottom-top)*fy
+}
+
+// axisPosition finds the segment v falls in and how far along it, clamped to
+// the axis ends.
+func axisPosition(axis []float64, v float64) (int, float64) {
+	n := len(axis)
+	if n < 2 {
+		return 0, 0
+	}
+	desc := axis[n-1] < axis[0]
+	before := func(a, b float64) bool {
+		if desc {
+			return a > b
+		}
+		return a < b
+	}
+	switch {
+	case !before(axis[0], v):
+		return 0, 0
+	case !before(v, axis[n-1]):
+		return n - 2, 1
+	}
+	i := 0
+	for i < n-2 && !before(v, axis[i+1]) {
+		i++
+	}
+	span := axis[i+1] - axis[i]
+	if span == 0 {
+		return i, 0
+	}
+	return i, (v - axis[i]) / span
+}
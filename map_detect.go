@@ -0,0 +1,336 @@
+package symbol
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// MapCandidate is a table DetectMaps believes it found: Rows by Cols values at
+// Address, against the axis symbols X and, for a map, Y. A curve has one row
+// and no Y.
+type MapCandidate struct {
+	Name        string
+	Address     uint32
+	Rows, Cols  int
+	ElementSize int // of the values; the axes have their own in X.Length
+	X, Y        *Symbol
+	// Header is set when the axes are preceded by their lengths, the
+	// NO_AXIS_PTS style record layouts.
+	Header bool
+	// Confidence, from 0 to 1, weighs how smooth the values are, how long the
+	// axes are and whether a length header backs them up.
+	Confidence float64
+}
+
+// Symbols are the candidate's values and its axes, holding nothing yet.
+func (c *MapCandidate) Symbols() []*Symbol {
+	z := &Symbol{Name: c.Name, Address: c.Address, Length: uint16(c.Rows * c.Cols * c.ElementSize), Type: elementType(c.ElementSize), Correctionfactor: 1}
+	out := []*Symbol{c.X, z}
+	if c.Y != nil {
+		out = []*Symbol{c.X, c.Y, z}
+	}
+	return out
+}
+
+// start is where the candidate's record begins, headers included.
+func (c *MapCandidate) start() uint32 {
+	start := min(c.X.Address, c.Address)
+	if c.Y != nil {
+		start = min(start, c.Y.Address)
+	}
+	if c.Header {
+		// Two headers at most, the length of the axes' elements each.
+		start -= min(start, uint32(c.X.Length)/uint32(c.Cols)*2)
+	}
+	return start
+}
+
+func (c *MapCandidate) end() uint32 {
+	return c.Address + uint32(c.Rows*c.Cols*c.ElementSize)
+}
+
+func elementType(size int) uint8 {
+	if size == 1 {
+		return CHAR
+	}
+	return 0
+}
+
+type DetectOpt func(*detectConfig)
+
+type detectConfig struct {
+	start, end    int
+	minConfidence float64
+}
+
+// WithDetectRange limits the search to image[start:end].
+func WithDetectRange(start, end int) DetectOpt {
+	return func(c *detectConfig) {
+		c.start, c.end = start, end
+	}
+}
+
+// WithDetectMinConfidence drops candidates below c, 0.5 by default.
+func WithDetectMinConfidence(c float64) DetectOpt {
+	return func(cfg *detectConfig) {
+		cfg.minConfidence = c
+	}
+}
+
+const (
+	detectMinAxis = 4
+	detectMaxAxis = 32
+)
+
+// DetectMaps searches a binary without a symbol table for tables: a strictly
+// increasing run of 8 or 16 bit big-endian values taken as an axis, followed
+// by a second axis or directly by values that vary smoothly against it. The
+// layouts tried are x y z and the length headed nx x ny y z and nx ny x y z,
+// for maps, and x z and n x z for curves. Candidates do not overlap; where two
+// would, the more confident one is kept. They come sorted by address.
+//
+// It is a heuristic: expect code and lookup tables among the low-confidence
+// results, and maps laid out any other way to be missed.
+func DetectMaps(image []byte, opts ...DetectOpt) []MapCandidate {
+	cfg := detectConfig{end: len(image), minConfidence: 0.5}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	cfg.start, cfg.end = max(cfg.start, 0), min(cfg.end, len(image))
+	if cfg.start >= cfg.end {
+		return nil
+	}
+
+	var found []MapCandidate
+	for _, size := range []int{1, 2} {
+		found = append(found, detect(image, cfg, size)...)
+	}
+	sort.SliceStable(found, func(i, j int) bool { return found[i].Confidence > found[j].Confidence })
+	var out []MapCandidate
+next:
+	for _, c := range found {
+		for _, o := range out {
+			if c.start() < o.end() && o.start() < c.end() {
+				continue next
+			}
+		}
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Address < out[j].Address })
+	return out
+}
+
+// detect runs the search with axes of size byte elements.
+func detect(image []byte, cfg detectConfig, size int) []MapCandidate {
+	base := cfg.start + (size-cfg.start%size)%size
+	n := (cfg.end - base) / size
+	v := make([]int, n)
+	for i := range v {
+		v[i] = readElement(image, base+i*size, size)
+	}
+	// run[i] is how many values from i on keep increasing.
+	run := make([]int, n+1)
+	for i := n - 1; i >= 0; i-- {
+		run[i] = 1
+		if i+1 < n && v[i] < v[i+1] {
+			run[i] += run[i+1]
+		}
+	}
+	at := func(i int) int {
+		if i < 0 || i >= n {
+			return -1
+		}
+		return v[i]
+	}
+	addr := func(i int) int { return base + i*size }
+
+	// Every reading that scores goes out; DetectMaps settles the overlaps.
+	var out []MapCandidate
+	for i := range n {
+		var cands []*MapCandidate
+		if h := at(i - 1); h >= detectMinAxis && h <= min(run[i], detectMaxAxis) {
+			nx, j := h, i+h
+			if ny := at(j); ny >= detectMinAxis && ny <= detectMaxAxis && ny <= run[j+1] {
+				// nx x ny y z
+				cands = append(cands, score(image, cfg, size, addr(i), nx, addr(j+1), ny, addr(j+1+ny), true))
+			}
+			// n x z
+			cands = append(cands, score(image, cfg, size, addr(i), nx, 0, 0, addr(j), true))
+			if nx := at(i - 2); nx >= detectMinAxis && nx <= min(run[i], detectMaxAxis) && h <= run[i+nx] {
+				// nx ny x y z
+				cands = append(cands, score(image, cfg, size, addr(i), nx, addr(i+nx), h, addr(i+nx+h), true))
+			}
+		}
+		if nx := run[i]; (i == 0 || v[i-1] >= v[i]) && nx >= detectMinAxis && nx <= detectMaxAxis {
+			j := i + nx
+			if ny := run[j]; ny >= detectMinAxis && ny <= detectMaxAxis {
+				// x y z
+				cands = append(cands, score(image, cfg, size, addr(i), nx, addr(j), ny, addr(j+ny), false))
+			}
+			// x z
+			cands = append(cands, score(image, cfg, size, addr(i), nx, 0, 0, addr(j), false))
+		}
+		var best *MapCandidate
+		for _, c := range cands {
+			if c != nil && (best == nil || c.Confidence > best.Confidence) {
+				best = c
+			}
+		}
+		if best != nil && best.Confidence >= cfg.minConfidence {
+			out = append(out, *best)
+		}
+	}
+	return out
+}
+
+func readElement(image []byte, off, size int) int {
+	if size == 1 {
+		return int(image[off])
+	}
+	return int(image[off])<<8 | int(image[off+1])
+}
+
+// score builds the candidate with nx axis values at x, ny at y (0 for a curve)
+// and the table at z, picking the value size that reads smoother.
+func score(image []byte, cfg detectConfig, size, x, nx, y, ny, z int, header bool) *MapCandidate {
+	rows := max(ny, 1)
+	var best *MapCandidate
+	for _, zs := range []int{size, 3 - size} {
+		if z%zs != 0 || z+rows*nx*zs > cfg.end {
+			continue
+		}
+		values := make([]int, rows*nx)
+		for k := range values {
+			values[k] = readElement(image, z+k*zs, zs)
+		}
+		smooth := smoothness(values, rows, nx)
+		if rows == 1 {
+			// A handful of values is easily smooth by chance, unless they
+			// also keep going one way, as most curves do.
+			if monotonic(values) {
+				smooth = math.Max(smooth, 0.9)
+			} else {
+				smooth *= math.Min(1, float64(nx-2)/8)
+			}
+		}
+		if smooth == 0 {
+			continue
+		}
+		axes := axisScore(image, x, nx, size)
+		if ny > 0 {
+			axes = (axes + axisScore(image, y, ny, size)) / 2
+		} else {
+			axes *= 0.8 // a lone run is likelier chance than two
+		}
+		conf := 0.45*smooth + 0.3*axes
+		if header {
+			conf += 0.25
+		}
+		if rows == 1 {
+			// Random bytes run up and smooth for a few values often
+			// enough; six points and up are seldom chance.
+			conf *= math.Min(1, float64(nx-1)/5)
+		}
+		if best != nil && conf <= best.Confidence {
+			continue
+		}
+		name := fmt.Sprintf("Map-%06X", z)
+		c := &MapCandidate{
+			Name: name, Address: uint32(z), Rows: rows, Cols: nx, ElementSize: zs, Header: header,
+			X:          &Symbol{Name: name + ".X", Address: uint32(x), Length: uint16(nx * size), Type: elementType(size), Correctionfactor: 1},
+			Confidence: math.Round(conf*100) / 100,
+		}
+		if ny > 0 {
+			c.Y = &Symbol{Name: name + ".Y", Address: uint32(y), Length: uint16(ny * size), Type: elementType(size), Correctionfactor: 1}
+		}
+		best = c
+	}
+	return best
+}
+
+// axisScore grows with the length of the run, which is less likely to be
+// chance the longer it is. A run of consecutive numbers, an index or text,
+// counts for half.
+func axisScore(image []byte, off, n, size int) float64 {
+	s := math.Min(1, float64(n-1)/7)
+	for k := 1; k < n; k++ {
+		if readElement(image, off+k*size, size)-readElement(image, off+(k-1)*size, size) != 1 {
+			return s
+		}
+	}
+	return s / 2
+}
+
+// smoothness is 1 for values that run straight along every row and column,
+// falling to 0 as the change between steps nears the size of the steps
+// themselves; random bytes sit well past that. Erased flash scores 0, any
+// other constant 0.3.
+func smoothness(values []int, rows, cols int) float64 {
+	lo, hi := values[0], values[0]
+	for _, v := range values {
+		lo, hi = min(lo, v), max(hi, v)
+	}
+	if lo == hi {
+		if lo == 0 || lo == 0xFF || lo == 0xFFFF {
+			return 0
+		}
+		return 0.3
+	}
+	var steps, bends float64
+	line := func(at func(k int) int, n int) {
+		for k := 1; k < n; k++ {
+			steps += math.Abs(float64(at(k) - at(k-1)))
+			if k > 1 {
+				bends += math.Abs(float64(at(k) - 2*at(k-1) + at(k-2)))
+			}
+		}
+	}
+	for r := range rows {
+		line(func(k int) int { return values[r*cols+k] }, cols)
+	}
+	for c := range cols {
+		line(func(k int) int { return values[k*cols+c] }, rows)
+	}
+	return math.Max(0, 1-bends/(2*steps)/0.6)
+}
+
+func monotonic(values []int) bool {
+	up, down := false, false
+	for k := 1; k < len(values); k++ {
+		up = up || values[k] > values[k-1]
+		down = down || values[k] < values[k-1]
+	}
+	return up != down
+}
+
+// CandidateDefinition turns candidates into a Definition, ready for
+// WithT5Definition or WithAW55Definition, or for Collection on a binary no
+// loader understands.
+func CandidateDefinition(title string, candidates []MapCandidate) *Definition {
+	def := &Definition{Title: title, Axes: make(AxisInformation)}
+	for i := range candidates {
+		c := &candidates[i]
+		info := Axis{X: c.X.Name, Z: c.Name, XDescription: c.X.Name, ZDescription: fmt.Sprintf("%dx%d, confidence %.2f", c.Rows, c.Cols, c.Confidence)}
+		if c.Y != nil {
+			info.Y, info.YDescription = c.Y.Name, c.Y.Name
+		}
+		def.Axes[c.Name] = info
+		for _, s := range c.Symbols() {
+			s.Number = len(def.Symbols)
+			def.Symbols = append(def.Symbols, s)
+		}
+	}
+	return def
+}
+
+// DetectCollection reads what DetectMaps finds in image into a Collection,
+// with the axes that lay it out.
+func DetectCollection(image []byte, opts ...DetectOpt) (*Collection, AxisInformation, error) {
+	def := CandidateDefinition("", DetectMaps(image, opts...))
+	c, err := def.Collection(image)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c, def.Axes, nil
+}
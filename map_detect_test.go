@@ -0,0 +1,66 @@
+package symbol
+
+import (
+	"encoding/binary"
+	"math/rand"
+	"testing"
+)
+
+func TestDetectMaps(t *testing.T) {
+	image := make([]byte, 0x10000)
+	rand.New(rand.NewSource(1)).Read(image)
+
+	// A 16 bit map in the nx x ny y z layout: rpm against load.
+	put16 := func(off int, vals ...int) int {
+		for _, v := range vals {
+			binary.BigEndian.PutUint16(image[off:], uint16(v))
+			off += 2
+		}
+		return off
+	}
+	off := put16(0x4000, 8, 500, 1000, 1500, 2000, 3000, 4000, 5000, 6000)
+	off = put16(off, 6, 10, 40, 70, 100, 130, 160)
+	const mapAt = 0x4000 + 2*(1+8+1+6)
+	for r := range 6 {
+		for c := range 8 {
+			off = put16(off, 200+r*30+c*12)
+		}
+	}
+	// An 8 bit curve without header: temperature against a correction.
+	curve := []byte{0x10, 0x20, 0x40, 0x60, 0x80, 0xA0, 90, 85, 80, 78, 75, 70}
+	image[0x9000] = 0xFF
+	copy(image[0x9001:], curve)
+
+	found := DetectMaps(image)
+	var m, c *MapCandidate
+	for i := range found {
+		switch found[i].Address {
+		case mapAt:
+			m = &found[i]
+		case 0x9007:
+			c = &found[i]
+		}
+	}
+	if m == nil || m.Rows != 6 || m.Cols != 8 || m.ElementSize != 2 || !m.Header || m.X.Address != 0x4002 || m.Y.Address != 0x4014 {
+		t.Fatalf("map: %+v in %+v", m, found)
+	}
+	if c == nil || c.Rows != 1 || c.Cols != 6 || c.ElementSize != 1 || c.Y != nil || c.X.Address != 0x9001 {
+		t.Fatalf("curve: %+v in %+v", c, found)
+	}
+	if len(found) > 4 {
+		t.Errorf("%d candidates in random data", len(found)-2)
+	}
+
+	col, axes, err := DetectCollection(image)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ax := axes[m.Name]
+	x, y := col.GetByName(ax.X), col.GetByName(ax.Y)
+	if x == nil || y == nil || x.Ints()[7] != 6000 || y.Ints()[5] != 160 {
+		t.Fatalf("axes %+v: %v %v", ax, x, y)
+	}
+	if z := col.GetByName(m.Name).Ints(); len(z) != 48 || z[47] != 200+5*30+7*12 {
+		t.Fatalf("%s = %v", m.Name, z)
+	}
+}
@@ -0,0 +1,249 @@
+package symbol
+
+import (
+	"fmt"
+	"math"
+)
+
+// The editing operations below work in physical units on a rectangular
+// Selection of the Map and write straight through to the Z symbol, so a Save
+// of the file the map came from picks them up. A value the data type cannot
+// hold is clamped to the nearest one it can, and the cells this happened to
+// are returned; that is not an error, the edit is still applied.
+
+// Cell addresses one value of a map, Z[Row][Col].
+type Cell struct {
+	Row, Col int
+}
+
+// Selection is a rectangle of cells.
+type Selection struct {
+	Row, Col   int
+	Rows, Cols int
+}
+
+// All selects the whole map.
+func (m *Map) All() Selection {
+	return Selection{Rows: m.Rows(), Cols: m.Cols()}
+}
+
+func (m *Map) check(sel Selection) error {
+	if sel.Rows < 1 || sel.Cols < 1 || sel.Row < 0 || sel.Col < 0 || sel.Row+sel.Rows > m.Rows() || sel.Col+sel.Cols > m.Cols() {
+		return fmt.Errorf("%s: selection %+v outside %dx%d: %w", m.Name, sel, m.Rows(), m.Cols(), ErrOffsetOutOfRange)
+	}
+	if m.z == nil {
+		return &SymbolError{ECU: m.ecu, Name: m.Name, Err: ErrSymbolNotFound}
+	}
+	return nil
+}
+
+// rawRange is what the symbol's data type holds, following Ints.
+func (s *Symbol) rawRange() (lo, hi int) {
+	signed := s.Type&SIGNED == SIGNED
+	char := s.Type&CHAR == CHAR
+	long := s.Type&LONG == LONG
+	switch {
+	case char && !long && !signed:
+		return 0, math.MaxUint8
+	case char && !long:
+		return math.MinInt8, math.MaxInt8
+	case long && !char && !signed:
+		return 0, math.MaxUint32
+	case long && !char:
+		return math.MinInt32, math.MaxInt32
+	case !char && !long && !signed:
+		return 0, math.MaxUint16
+	}
+	return math.MinInt16, math.MaxInt16
+}
+
+// apply sets every selected cell to f of its position and current value,
+// clamps, and writes the whole map back to its symbol.
+func (m *Map) apply(sel Selection, f func(r, c int, v float64) float64) ([]Cell, error) {
+	if err := m.check(sel); err != nil {
+		return nil, err
+	}
+	next := make([][]float64, m.Rows())
+	for r := range m.Z {
+		next[r] = append([]float64(nil), m.Z[r]...)
+	}
+	for r := sel.Row; r < sel.Row+sel.Rows; r++ {
+		for c := sel.Col; c < sel.Col+sel.Cols; c++ {
+			next[r][c] = f(r, c, m.Z[r][c])
+		}
+	}
+	return m.store(next)
+}
+
+func (m *Map) store(next [][]float64) ([]Cell, error) {
+	data, saturated, err := m.encode(next)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.z.SetData(data); err != nil {
+		return nil, err
+	}
+	m.Z = next
+	return saturated, nil
+}
+
+// encode clamps next to what the Z symbol holds, in place, and returns its
+// data without writing it, so a caller can check several maps before storing
+// any of them.
+func (m *Map) encode(next [][]float64) ([]byte, []Cell, error) {
+	var saturated []Cell
+	lo, hi := m.z.rawRange()
+	offset := m.z.offset()
+	factor := m.z.Correctionfactor
+	if factor == 0 {
+		// Every physical value would map to the same raw one.
+		return nil, nil, &SymbolError{ECU: m.ecu, Name: m.z.Name, Err: ErrZeroFactor}
+	}
+	flat := make([]float64, 0, m.Rows()*m.Cols())
+	for r := range next {
+		for c, v := range next[r] {
+			raw := math.Round((v - offset) / factor)
+			if clamped := math.Min(math.Max(raw, float64(lo)), float64(hi)); clamped != raw {
+				saturated = append(saturated, Cell{r, c})
+				raw = clamped
+			}
+			// Keep Z at what the ECU will actually see.
+			next[r][c] = raw*factor + offset
+			flat = append(flat, next[r][c])
+		}
+	}
+	data := m.z.EncodeFloat64s(flat)
+	if len(data) != int(m.z.Length) {
+		return nil, nil, &SymbolError{ECU: m.ecu, Name: m.z.Name, Address: m.z.Address, Expected: int(m.z.Length), Actual: len(data), Err: ErrDataLength}
+	}
+	return data, saturated, nil
+}
+
+func (m *Map) SetCell(row, col int, v float64) ([]Cell, error) {
+	return m.SetRange(Selection{row, col, 1, 1}, v)
+}
+
+func (m *Map) SetRange(sel Selection, v float64) ([]Cell, error) {
+	return m.apply(sel, func(_, _ int, _ float64) float64 { return v })
+}
+
+func (m *Map) AddRange(sel Selection, delta float64) ([]Cell, error) {
+	return m.apply(sel, func(_, _ int, v float64) float64 { return v + delta })
+}
+
+func (m *Map) MultiplyRange(sel Selection, factor float64) ([]Cell, error) {
+	return m.apply(sel, func(_, _ int, v float64) float64 { return v * factor })
+}
+
+// AddPercent changes the selection by pct percent of each cell's own value.
+func (m *Map) AddPercent(sel Selection, pct float64) ([]Cell, error) {
+	return m.MultiplyRange(sel, 1+pct/100)
+}
+
+// Smooth replaces every selected cell with the mean of itself and its eight
+// neighbours, as far as they exist. All cells read the values from before the
+// operation.
+func (m *Map) Smooth(sel Selection) ([]Cell, error) {
+	return m.apply(sel, func(r, c int, _ float64) float64 {
+		var sum float64
+		var n int
+		for i := max(r-1, 0); i <= min(r+1, m.Rows()-1); i++ {
+			for j := max(c-1, 0); j <= min(c+1, m.Cols()-1); j++ {
+				sum += m.Z[i][j]
+				n++
+			}
+		}
+		return sum / float64(n)
+	})
+}
+
+// Interpolate replaces the selection with a bilinear blend of its four corner
+// cells, spaced by the physical breakpoints rather than by cell count.
+func (m *Map) Interpolate(sel Selection) ([]Cell, error) {
+	r0, r1 := sel.Row, sel.Row+sel.Rows-1
+	c0, c1 := sel.Col, sel.Col+sel.Cols-1
+	return m.apply(sel, func(r, c int, _ float64) float64 {
+		fx, fy := m.fraction(m.X, c0, c1, c), m.fraction(m.Y, r0, r1, r)
+		top := m.Z[r0][c0] + (m.Z[r0][c1]-m.Z[r0][c0])*fx
+		bottom := m.Z[r1][c0] + (m.Z[r1][c1]-m.Z[r1][c0])*fx
+		return top + (bottom-top)*fy
+	})
+}
+
+// Fill replaces the selection from the cells around it: each cell becomes the
+// interpolation between the nearest unselected cells left and right of it and
+// above and below it, averaged over whichever directions have both.
+func (m *Map) Fill(sel Selection) ([]Cell, error) {
+	left, right := sel.Col-1, sel.Col+sel.Cols
+	top, bottom := sel.Row-1, sel.Row+sel.Rows
+	horizontal := left >= 0 && right < m.Cols()
+	vertical := top >= 0 && bottom < m.Rows()
+	if !horizontal && !vertical {
+		return nil, fmt.Errorf("%s: selection %+v has no neighbours on two opposite sides: %w", m.Name, sel, ErrOffsetOutOfRange)
+	}
+	return m.apply(sel, func(r, c int, _ float64) float64 {
+		var sum float64
+		var n int
+		if horizontal {
+			f := m.fraction(m.X, left, right, c)
+			sum += m.Z[r][left] + (m.Z[r][right]-m.Z[r][left])*f
+			n++
+		}
+		if vertical {
+			f := m.fraction(m.Y, top, bottom, r)
+			sum += m.Z[top][c] + (m.Z[bottom][c]-m.Z[top][c])*f
+			n++
+		}
+		return sum / float64(n)
+	})
+}
+
+// fraction is how far breakpoint i lies from a to b on axis, falling back to
+// cell count when the axis is flat or implicit.
+func (m *Map) fraction(axis []float64, a, b, i int) float64 {
+	if a == b {
+		return 0
+	}
+	if span := axis[b] - axis[a]; span != 0 {
+		return (axis[i] - axis[a]) / span
+	}
+	return float64(i-a) / float64(b-a)
+}
+
+// Copy returns the selected values, Z[row][col] relative to the selection.
+func (m *Map) Copy(sel Selection) ([][]float64, error) {
+	if err := m.check(sel); err != nil {
+		return nil, err
+	}
+	out := make([][]float64, sel.Rows)
+	for r := range out {
+		out[r] = append([]float64(nil), m.Z[sel.Row+r][sel.Col:sel.Col+sel.Cols]...)
+	}
+	return out, nil
+}
+
+// Paste writes values copied with Copy with their top left cell at row, col.
+func (m *Map) Paste(row, col int, values [][]float64) ([]Cell, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	sel := Selection{Row: row, Col: col, Rows: len(values), Cols: len(values[0])}
+	for _, v := range values {
+		if len(v) != sel.Cols {
+			return nil, fmt.Errorf("%s: ragged paste: %w", m.Name, ErrDataLength)
+		}
+	}
+	return m.apply(sel, func(r, c int, _ float64) float64 { return values[r-row][c-col] })
+}
+
+// CopyFrom pastes all of src, which must have the same shape.
+func (m *Map) CopyFrom(src *Map) ([]Cell, error) {
+	if src.Rows() != m.Rows() || src.Cols() != m.Cols() {
+		return nil, fmt.Errorf("%s is %dx%d, %s is %dx%d: %w", src.Name, src.Rows(), src.Cols(), m.Name, m.Rows(), m.Cols(), ErrDataLength)
+	}
+	values, err := src.Copy(src.All())
+	if err != nil {
+		return nil, err
+	}
+	return m.Paste(0, 0, values)
+}
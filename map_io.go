@@ -0,0 +1,196 @@
+package symbol
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// MapFormat is a text form a map can be exported to and imported from.
+type MapFormat string
+
+const (
+	// MapCSV is the spreadsheet layout: X breakpoints across the first row,
+	// each following row its Y breakpoint and then the values. The corner cell
+	// names the axes as "Y\X".
+	MapCSV MapFormat = "csv"
+	// MapJSON carries the axes, units and values, see MapDocument.
+	MapJSON MapFormat = "json"
+)
+
+// MapDocument is the JSON form of a map.
+type MapDocument struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description,omitempty"`
+	Unit        string      `json:"unit,omitempty"`
+	X           MapAxis     `json:"x"`
+	Y           MapAxis     `json:"y"`
+	Values      [][]float64 `json:"values"` // values[row][col], one row per Y breakpoint
+}
+
+type MapAxis struct {
+	Name        string    `json:"name,omitempty"` // empty for an implicit axis
+	Unit        string    `json:"unit,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Values      []float64 `json:"values"`
+}
+
+// ExportMap writes the map called name in the given format, every value
+// rounded to the precision GetPrecision gives its correction factor.
+func ExportMap(fw FirmwareFile, name string, format MapFormat) ([]byte, error) {
+	m, err := NewMap(fw, name)
+	if err != nil {
+		return nil, err
+	}
+	zfac := m.z.Correctionfactor
+	switch format {
+	case MapCSV:
+		var buf bytes.Buffer
+		w := csv.NewWriter(&buf)
+		head := []string{m.YName + `\` + m.XName}
+		for _, x := range m.X {
+			head = append(head, formatPhysical(x, m.xfac))
+		}
+		w.Write(head)
+		for r, y := range m.Y {
+			row := []string{formatPhysical(y, m.yfac)}
+			for _, v := range m.Z[r] {
+				row = append(row, formatPhysical(v, zfac))
+			}
+			w.Write(row)
+		}
+		w.Flush()
+		return buf.Bytes(), w.Error()
+	case MapJSON:
+		round := func(values []float64, factor float64) []float64 {
+			out := make([]float64, len(values))
+			for i, v := range values {
+				out[i], _ = strconv.ParseFloat(formatPhysical(v, factor), 64)
+			}
+			return out
+		}
+		doc := MapDocument{
+			Name:        m.Name,
+			Description: m.ZDescription,
+			Unit:        m.ZUnit,
+			X:           MapAxis{Name: m.XName, Unit: m.XUnit, Description: m.XDescription, Values: round(m.X, m.xfac)},
+			Y:           MapAxis{Name: m.YName, Unit: m.YUnit, Description: m.YDescription, Values: round(m.Y, m.yfac)},
+		}
+		for _, row := range m.Z {
+			doc.Values = append(doc.Values, round(row, zfac))
+		}
+		return json.MarshalIndent(doc, "", "  ")
+	}
+	return nil, fmt.Errorf("map format %q: %w", format, ErrUnknownFormat)
+}
+
+// ImportMap writes data, as produced by ExportMap, back into the map called
+// name. It refuses anything that does not have the map's shape, or whose axes
+// differ from the map's by more than half a raw step: the values would land on
+// other breakpoints than they were edited for. The cells that had to be clamped
+// to what the data type holds are returned.
+func ImportMap(fw FirmwareFile, name string, format MapFormat, data []byte) ([]Cell, error) {
+	m, err := NewMap(fw, name)
+	if err != nil {
+		return nil, err
+	}
+	var x, y []float64
+	var z [][]float64
+	switch format {
+	case MapCSV:
+		x, y, z, err = parseMapCSV(data)
+	case MapJSON:
+		var doc MapDocument
+		if err = json.Unmarshal(data, &doc); err == nil {
+			x, y, z = doc.X.Values, doc.Y.Values, doc.Values
+		}
+	default:
+		err = fmt.Errorf("map format %q: %w", format, ErrUnknownFormat)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(x) != m.Cols() || len(y) != m.Rows() || len(z) != m.Rows() {
+		return nil, &SymbolError{ECU: m.ecu, Name: name, Err: fmt.Errorf("%dx%d, want %dx%d: %w", len(y), len(x), m.Rows(), m.Cols(), ErrMapShape)}
+	}
+	for r := range z {
+		if len(z[r]) != m.Cols() {
+			return nil, &SymbolError{ECU: m.ecu, Name: name, Err: fmt.Errorf("row %d has %d values, want %d: %w", r, len(z[r]), m.Cols(), ErrMapShape)}
+		}
+	}
+	for _, a := range []struct {
+		id         string
+		got, want  []float64
+		correction float64
+	}{{"X", x, m.X, m.xfac}, {"Y", y, m.Y, m.yfac}} {
+		tolerance := math.Max(math.Abs(a.correction)/2, 1e-9)
+		for i := range a.want {
+			if math.Abs(a.got[i]-a.want[i]) > tolerance {
+				return nil, &SymbolError{ECU: m.ecu, Name: name, Err: fmt.Errorf("%s[%d] is %g, want %g: %w", a.id, i, a.got[i], a.want[i], ErrAxisMismatch)}
+			}
+		}
+	}
+	return m.store(z)
+}
+
+func parseMapCSV(data []byte) (x, y []float64, z [][]float64, err error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	// Spreadsheets in locales with a decimal comma separate with semicolons.
+	if first, _, _ := bytes.Cut(data, []byte("\n")); bytes.Count(first, []byte(";")) > bytes.Count(first, []byte(",")) {
+		r.Comma = ';'
+	}
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if len(records) < 2 {
+		return nil, nil, nil, fmt.Errorf("csv has %d lines: %w", len(records), ErrMapShape)
+	}
+	parse := func(line int, fields []string) ([]float64, error) {
+		out := make([]float64, 0, len(fields))
+		for col, f := range fields {
+			f = strings.TrimSpace(f)
+			if r.Comma == ';' {
+				f = strings.ReplaceAll(f, ",", ".")
+			}
+			v, err := strconv.ParseFloat(f, 64)
+			if err != nil {
+				return nil, fmt.Errorf("csv line %d, field %d: %w", line+1, col+1, err)
+			}
+			out = append(out, v)
+		}
+		return out, nil
+	}
+	if x, err = parse(0, records[0][1:]); err != nil {
+		return nil, nil, nil, err
+	}
+	for i, rec := range records[1:] {
+		if len(rec) == 0 || len(rec) == 1 && strings.TrimSpace(rec[0]) == "" {
+			continue
+		}
+		values, err := parse(i+1, rec)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		y = append(y, values[0])
+		z = append(z, values[1:])
+	}
+	return x, y, z, nil
+}
+
+// formatPhysical prints v with the decimals GetPrecision gives factor, or as
+// many as it takes for a factor it does not know.
+func formatPhysical(v, factor float64) string {
+	prec := GetPrecision(factor)
+	if prec == 0 && factor != math.Trunc(factor) {
+		prec = -1
+	}
+	return strconv.FormatFloat(v, 'f', prec, 64)
+}
@@ -0,0 +1,312 @@
+package symbol
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func testSymbol(name string, typ uint8, factor float64, raw ...int) *Symbol {
+	s := &Symbol{Name: name, Type: typ, Correctionfactor: factor}
+	data := s.EncodeInts(raw)
+	s.Length = uint16(len(data))
+	s.SetData(data)
+	return s
+}
+
+// testSymbolAt is testSymbol at address addr.
+func testSymbolAt(name string, addr uint32, typ uint8, factor float64, raw ...int) *Symbol {
+	s := testSymbol(name, typ, factor, raw...)
+	s.Address = addr
+	return s
+}
+
+// t5Symbol is testSymbol with the offset the T5 loader gives name.
+func t5Symbol(name string, typ uint8, factor float64, raw ...int) *Symbol {
+	s := testSymbol(name, typ, factor, raw...)
+	s.Offset = T5Offsets[name]
+	return s
+}
+
+func TestMapLookup(t *testing.T) {
+	fw := &T5File{Collection: NewCollection(
+		testSymbol("Pwm_ind_trot!", CHAR, 1, 0, 50, 100),
+		testSymbol("Pwm_ind_rpm!", 0, 10, 100, 200),
+		t5Symbol("Tryck_mat!", CHAR, 0.01, 100, 150, 200, 120, 170, 220),
+		testSymbol("Batt_korr_tab!", 0, 0.004, 1000, 900, 800, 700, 600, 500, 400, 300, 200, 100, 0),
+	)}
+	m, err := NewMap(fw, "Tryck_mat!")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Rows() != 2 || m.Cols() != 3 || m.Y[1] != 2000 || m.XName != "Pwm_ind_trot!" {
+		t.Fatalf("map %v: X %v Y %v", m, m.X, m.Y)
+	}
+	for _, c := range []struct{ x, y, want float64 }{
+		{0, 1000, 0},     // T5Offsets puts -1 on Tryck_mat!
+		{25, 1500, 0.35}, // bilinear
+		{100, 2000, 1.2},
+		{-10, 500, 0}, // clamped low
+		{200, 5000, 1.2},
+	} {
+		if got := m.Lookup(c.x, c.y); math.Abs(got-c.want) > 1e-9 {
+			t.Errorf("Lookup(%g, %g) = %g, want %g", c.x, c.y, got, c.want)
+		}
+	}
+
+	// T5Offsets is the T5 loader's; a symbol of the same name from anywhere
+	// else keeps the offset it was given.
+	if got := testSymbol("Tryck_mat!", CHAR, 0.01, 100).Float64s()[0]; got != 1 {
+		t.Errorf("Tryck_mat! outside a T5 file = %g, want 1", got)
+	}
+
+	// Batt_korr_tab! has no axis symbols, so it is one column indexed 0-10.
+	b, err := NewMap(fw, "Batt_korr_tab!")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Rows() != 11 || b.Cols() != 1 || b.YName != "" || b.Y[0] != 0 || b.Y[10] != 10 {
+		t.Fatalf("Batt_korr_tab! is %dx%d, Y %v", b.Rows(), b.Cols(), b.Y)
+	}
+	if got := b.Lookup(0, 0.5); math.Abs(got-3.8) > 1e-9 {
+		t.Errorf("Batt_korr_tab! at 0.5 = %g, want 3.8", got)
+	}
+
+	// An axis the layout names but the file lacks is not made up.
+	if _, err := NewMap(&T5File{Collection: NewCollection(fw.GetByName("Pwm_ind_trot!"), fw.GetByName("Tryck_mat!"))}, "Tryck_mat!"); !errors.Is(err, ErrSymbolNotFound) {
+		t.Errorf("missing Y axis: %v, want ErrSymbolNotFound", err)
+	}
+
+	// A symbol holding no data is not a map.
+	fw.Add(&Symbol{Name: "Empty!", Correctionfactor: 1})
+	if _, err := NewMap(fw, "Empty!"); !errors.Is(err, ErrDataLength) {
+		t.Errorf("empty map: %v, want ErrDataLength", err)
+	}
+}
+
+func TestMapEdit(t *testing.T) {
+	fw := &T5File{Collection: NewCollection(
+		testSymbol("Pwm_ind_trot!", CHAR, 1, 0, 50, 100),
+		testSymbol("Pwm_ind_rpm!", 0, 10, 100, 200, 300),
+		t5Symbol("Tryck_mat!", CHAR, 0.01, 100, 150, 200, 120, 170, 220, 140, 190, 240),
+	)}
+	m, err := NewMap(fw, "Tryck_mat!")
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw := func() []int { return fw.GetByName("Tryck_mat!").Ints() }
+
+	if sat, err := m.SetCell(0, 0, 0.25); err != nil || len(sat) != 0 {
+		t.Fatal(sat, err)
+	}
+	if got := raw()[0]; got != 125 {
+		t.Fatalf("raw after SetCell = %d, want 125", got)
+	}
+
+	// Tryck_mat! is unsigned 8 bit with offset -1: 1.55 is the most it holds.
+	sat, err := m.AddRange(Selection{Row: 2, Col: 1, Rows: 1, Cols: 2}, 0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sat) != 1 || sat[0] != (Cell{2, 2}) || math.Abs(m.Z[2][2]-1.55) > 1e-9 || raw()[8] != 255 {
+		t.Fatalf("saturation %v, Z %v, raw %v", sat, m.Z[2], raw())
+	}
+
+	if _, err := m.AddPercent(Selection{Row: 1, Col: 0, Rows: 1, Cols: 1}, 50); err != nil {
+		t.Fatal(err)
+	}
+	if got := raw()[3]; got != 130 { // 0.2 * 1.5 = 0.3
+		t.Fatalf("raw after AddPercent = %d, want 130", got)
+	}
+
+	values, err := m.Copy(Selection{Row: 0, Col: 0, Rows: 2, Cols: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Paste(1, 1, values); err != nil {
+		t.Fatal(err)
+	}
+	if m.Z[2][2] != values[1][1] {
+		t.Fatalf("paste: Z[2][2] = %g, want %g", m.Z[2][2], values[1][1])
+	}
+
+	// Interpolating the middle column from the corners of a row puts it
+	// halfway, since the throttle breakpoints are evenly spaced.
+	if _, err := m.Interpolate(Selection{Row: 0, Col: 0, Rows: 1, Cols: 3}); err != nil {
+		t.Fatal(err)
+	}
+	if want := (m.Z[0][0] + m.Z[0][2]) / 2; math.Abs(m.Z[0][1]-want) > 0.006 {
+		t.Fatalf("interpolated %g, want %g", m.Z[0][1], want)
+	}
+
+	if _, err := m.Fill(Selection{Row: 1, Col: 1, Rows: 1, Cols: 1}); err != nil {
+		t.Fatal(err)
+	}
+	h := (m.Z[1][0] + m.Z[1][2]) / 2
+	v := (m.Z[0][1] + m.Z[2][1]) / 2
+	if math.Abs(m.Z[1][1]-(h+v)/2) > 0.006 {
+		t.Fatalf("filled %g, want %g", m.Z[1][1], (h+v)/2)
+	}
+	if _, err := m.Fill(Selection{Row: 0, Col: 0, Rows: 1, Cols: 1}); err == nil {
+		t.Fatal("fill of a corner succeeded")
+	}
+
+	if _, err := m.Smooth(m.All()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.SetRange(Selection{Row: 2, Col: 2, Rows: 2, Cols: 1}, 0); err == nil {
+		t.Fatal("selection past the edge accepted")
+	}
+	// What was written is what a fresh read sees.
+	again, _ := NewMap(fw, "Tryck_mat!")
+	for r := range m.Z {
+		for c := range m.Z[r] {
+			if again.Z[r][c] != m.Z[r][c] {
+				t.Fatalf("Z[%d][%d] = %g in the map, %g in the symbol", r, c, m.Z[r][c], again.Z[r][c])
+			}
+		}
+	}
+
+	m.z.Correctionfactor = 0
+	before := raw()
+	if _, err := m.SetCell(0, 0, 1); !errors.Is(err, ErrZeroFactor) {
+		t.Fatalf("write with factor 0: %v", err)
+	}
+	if got := raw(); got[0] != before[0] {
+		t.Fatalf("write with factor 0 changed the data to %d", got[0])
+	}
+}
+
+func TestRescaleAxis(t *testing.T) {
+	fw := &T7File{Collection: NewCollection(
+		testSymbol("BFuelCal.AirXSP", 0, 1, 100, 200, 300),
+		testSymbol("BFuelCal.RpmYSP", 0, 1, 1000, 2000),
+		testSymbol("BFuelCal.Map", 0, 1, 10, 20, 30, 40, 60, 80),
+		testSymbol("BFuelCal.E85Map", 0, 1, 1, 2, 3, 4, 5, 6),
+	)}
+	if deps := Dependents(fw, "BFuelCal.AirXSP"); len(deps) != 2 || deps[0] != "BFuelCal.E85Map" {
+		t.Fatalf("dependents %v", deps)
+	}
+	before, _ := NewMap(fw, "BFuelCal.Map")
+
+	out, err := RescaleAxis(fw, "BFuelCal.AirXSP", []float64{150, 300, 500})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != 2 {
+		t.Fatalf("rescaled %v", out)
+	}
+	after, _ := NewMap(fw, "BFuelCal.Map")
+	if after.X[2] != 500 {
+		t.Fatalf("axis %v", after.X)
+	}
+	// Same behaviour at every input the old map covered, clamped beyond.
+	for _, x := range []float64{150, 300, 500} {
+		for _, y := range before.Y {
+			if a, b := after.Lookup(x, y), before.Lookup(x, y); math.Abs(a-b) > 0.5 {
+				t.Errorf("at %g,%g: %g after, %g before", x, y, a, b)
+			}
+		}
+	}
+	if got := fw.GetByName("BFuelCal.Map").Ints(); got[0] != 15 || got[5] != 80 {
+		t.Fatalf("BFuelCal.Map raw %v", got)
+	}
+
+	if _, err := RescaleAxis(fw, "BFuelCal.AirXSP", []float64{300, 200, 400}); !errors.Is(err, ErrAxisOrder) {
+		t.Fatalf("unordered breakpoints: %v", err)
+	}
+	if _, err := RescaleAxis(fw, "BFuelCal.AirXSP", []float64{1, 2}); !errors.Is(err, ErrDataLength) {
+		t.Fatalf("wrong number of breakpoints: %v", err)
+	}
+
+	// A dependent that cannot be written stops the rescale before the ones
+	// sorted ahead of it are.
+	e85 := fw.GetByName("BFuelCal.E85Map").Ints()
+	fw.GetByName("BFuelCal.Map").Correctionfactor = 0
+	if _, err := RescaleAxis(fw, "BFuelCal.AirXSP", []float64{100, 300, 600}); !errors.Is(err, ErrZeroFactor) {
+		t.Fatalf("dependent with factor 0: %v", err)
+	}
+	if got := fw.GetByName("BFuelCal.E85Map").Ints(); !slices.Equal(got, e85) {
+		t.Fatalf("BFuelCal.E85Map written anyway: %v, was %v", got, e85)
+	}
+	if got := fw.GetByName("BFuelCal.AirXSP").Ints(); got[2] != 500 {
+		t.Fatalf("axis written anyway: %v", got)
+	}
+
+	// Maps only the file's own layout knows depend on the axis too.
+	t5 := &T5File{
+		Collection: NewCollection(testSymbol("Ign_map_0_x_axis!", 0, 1, 10, 20), testSymbol("Custom!", 0, 1, 1, 2)),
+		axes:       AxisInformation{"Custom!": {X: "Ign_map_0_x_axis!", Z: "Custom!"}},
+	}
+	if deps := Dependents(t5, "Ign_map_0_x_axis!"); !slices.Contains(deps, "Custom!") {
+		t.Fatalf("file layout dependents %v", deps)
+	}
+}
+
+func TestExportImportMap(t *testing.T) {
+	fw := &T5File{Collection: NewCollection(
+		testSymbol("Pwm_ind_trot!", CHAR, 1, 0, 50, 100),
+		testSymbol("Pwm_ind_rpm!", 0, 10, 100, 200),
+		t5Symbol("Tryck_mat!", CHAR, 0.01, 100, 150, 200, 120, 170, 220),
+	)}
+	out, err := ExportMap(fw, "Tryck_mat!", MapCSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Pwm_ind_rpm!\\Pwm_ind_trot!,0,50,100\n1000,0.00,0.50,1.00\n2000,0.20,0.70,1.20\n"
+	if string(out) != want {
+		t.Fatalf("csv:\n%s\nwant:\n%s", out, want)
+	}
+
+	edited := strings.Replace(string(out), "0.70", "0.90", 1)
+	if _, err := ImportMap(fw, "Tryck_mat!", MapCSV, []byte(edited)); err != nil {
+		t.Fatal(err)
+	}
+	if got := fw.GetByName("Tryck_mat!").Ints(); got[4] != 190 {
+		t.Fatalf("raw %v", got)
+	}
+	semi := "x;0;50;100\n1000;0,00;0,50;1,00\n2000;0,20;0,70;3,00\n"
+	saturated, err := ImportMap(fw, "Tryck_mat!", MapCSV, []byte(semi))
+	if err != nil || len(saturated) != 1 || saturated[0] != (Cell{1, 2}) {
+		t.Fatalf("semicolon csv: %v %v", saturated, err)
+	}
+
+	for _, bad := range []struct {
+		csv string
+		err error
+	}{
+		{"x,0,50\n1000,0,0\n2000,0,0\n", ErrMapShape},
+		{"x,0,50,100\n1000,0,0,0\n", ErrMapShape},
+		{"x,0,55,100\n1000,0,0,0\n2000,0,0,0\n", ErrAxisMismatch},
+	} {
+		if _, err := ImportMap(fw, "Tryck_mat!", MapCSV, []byte(bad.csv)); !errors.Is(err, bad.err) {
+			t.Errorf("%q: %v, want %v", bad.csv, err, bad.err)
+		}
+	}
+
+	out, err = ExportMap(fw, "Tryck_mat!", MapJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc MapDocument
+	if err := json.Unmarshal(out, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.X.Name != "Pwm_ind_trot!" || doc.Y.Values[1] != 2000 || doc.Values[1][2] != 1.55 {
+		t.Fatalf("json %+v", doc)
+	}
+	doc.Values[0][0] = 0.3
+	in, _ := json.Marshal(doc)
+	if _, err := ImportMap(fw, "Tryck_mat!", MapJSON, in); err != nil {
+		t.Fatal(err)
+	}
+	if got := fw.GetByName("Tryck_mat!").Ints(); got[0] != 130 {
+		t.Fatalf("raw %v", got)
+	}
+	if _, err := ExportMap(fw, "Tryck_mat!", "xlsx"); !errors.Is(err, ErrUnknownFormat) {
+		t.Fatalf("xlsx: %v", err)
+	}
+}
@@ -0,0 +1,130 @@
+package symbol
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+)
+
+// MergeResult is what Merge did to theirs.
+type MergeResult struct {
+	Applied   []string        `json:"applied,omitempty"`   // symbols that took changes from ours
+	Conflicts []MergeConflict `json:"conflicts,omitempty"` // cells changed on both sides; theirs kept
+	Flagged   []MergeFlag     `json:"flagged,omitempty"`   // symbols left alone, see Reason
+}
+
+// MergeConflict is one cell ours and theirs both changed, differently. The
+// values are physical, in theirs' scaling.
+type MergeConflict struct {
+	Symbol string `json:"symbol"`
+	Cell
+	Base   float64 `json:"base"`
+	Ours   float64 `json:"ours"`
+	Theirs float64 `json:"theirs"`
+}
+
+type MergeFlag struct {
+	Symbol string `json:"symbol"`
+	Reason string `json:"reason"`
+}
+
+// Merge carries the calibration changes of ours, made on top of base, over to
+// theirs, a newer version of base. Symbols are matched by name and merged cell
+// by cell: a cell ours changed is taken wherever theirs still holds the base
+// value, and is a conflict where theirs changed it too. A symbol whose length,
+// or whose axes' lengths, differ between the versions cannot be merged cell by
+// cell and is flagged instead. The changes are written into theirs with
+// SetData, so they show up in its journal and its next Save.
+func Merge(base, ours, theirs FirmwareFile) (*MergeResult, error) {
+	res := &MergeResult{}
+	ecu := ecuOf(theirs)
+	flag := func(name, format string, args ...any) {
+		res.Flagged = append(res.Flagged, MergeFlag{Symbol: name, Reason: fmt.Sprintf(format, args...)})
+	}
+	for _, so := range ours.Symbols() {
+		sb := base.GetByName(so.Name)
+		if sb != nil && bytes.Equal(so.data, sb.data) {
+			continue
+		}
+		st := theirs.GetByName(so.Name)
+		switch {
+		case sb == nil:
+			flag(so.Name, "not in base")
+			continue
+		case st == nil:
+			flag(so.Name, "not in theirs")
+			continue
+		case len(so.data) != len(sb.data) || len(st.data) != len(sb.data):
+			flag(so.Name, "length changed: base %d, ours %d, theirs %d", len(sb.data), len(so.data), len(st.data))
+			continue
+		}
+		if reason := axisShapeChange(fileInfo(ecu, theirs, so.Name), base, ours, theirs); reason != "" {
+			flag(so.Name, "%s", reason)
+			continue
+		}
+
+		size := st.elementSize()
+		merged := bytes.Clone(st.data)
+		changed := false
+		var conflicts []int
+		for i := 0; i+size <= len(merged); i += size {
+			b, o, t := sb.data[i:i+size], so.data[i:i+size], st.data[i:i+size]
+			switch {
+			case bytes.Equal(o, b), bytes.Equal(o, t):
+			case bytes.Equal(t, b):
+				copy(merged[i:], o)
+				changed = true
+			default:
+				conflicts = append(conflicts, i/size)
+			}
+		}
+		if len(conflicts) > 0 {
+			cols := mapCols(theirs, st)
+			vb, vo, vt := st.BytesToFloat64s(sb.data), st.BytesToFloat64s(so.data), st.Float64s()
+			for _, k := range conflicts {
+				res.Conflicts = append(res.Conflicts, MergeConflict{Symbol: so.Name, Cell: Cell{Row: k / cols, Col: k % cols}, Base: vb[k], Ours: vo[k], Theirs: vt[k]})
+			}
+		}
+		if changed {
+			if err := st.SetData(merged); err != nil {
+				return res, err
+			}
+			res.Applied = append(res.Applied, so.Name)
+		}
+	}
+	sort.Strings(res.Applied)
+	return res, nil
+}
+
+// axisShapeChange explains how the axes of a map differ in length between the
+// versions, or returns "" when they do not.
+func axisShapeChange(ax Axis, base, ours, theirs FirmwareFile) string {
+	for _, name := range []string{ax.X, ax.Y} {
+		if name == "" {
+			continue
+		}
+		var lengths [3]int
+		for i, fw := range []FirmwareFile{base, ours, theirs} {
+			if s := fw.GetByName(name); s != nil {
+				lengths[i] = len(s.data)
+			}
+		}
+		if lengths[0] != lengths[1] || lengths[0] != lengths[2] {
+			return fmt.Sprintf("axis %s changed: base %d, ours %d, theirs %d bytes", name, lengths[0], lengths[1], lengths[2])
+		}
+	}
+	return ""
+}
+
+// elementSize is the width of one value, following Ints.
+func (s *Symbol) elementSize() int {
+	char := s.Type&CHAR == CHAR
+	long := s.Type&LONG == LONG
+	switch {
+	case char && !long:
+		return 1
+	case long && !char:
+		return 4
+	}
+	return 2
+}
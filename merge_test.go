@@ -0,0 +1,48 @@
+package symbol
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	base := NewCollection(
+		testSymbol("Tryck_mat!", CHAR, 0.01, 100, 150, 200, 120, 170, 220),
+		testSymbol("scalar", 0, 1, 5),
+		testSymbol("grown", 0, 1, 1, 2),
+	)
+	ours := NewCollection(
+		testSymbol("Tryck_mat!", CHAR, 0.01, 110, 150, 210, 120, 170, 220),
+		testSymbol("scalar", 0, 1, 6),
+		testSymbol("grown", 0, 1, 1, 3),
+	)
+	theirs := NewCollection(
+		testSymbol("Tryck_mat!", CHAR, 0.01, 100, 155, 200, 120, 170, 220),
+		testSymbol("scalar", 0, 1, 7),
+		testSymbol("grown", 0, 1, 1, 2, 3),
+	)
+
+	res, err := Merge(base, ours, theirs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Tryck_mat! merges cleanly: ours touched cells 0 and 2, theirs cell 1.
+	if !slices.Equal(res.Applied, []string{"Tryck_mat!"}) {
+		t.Fatalf("applied %v", res.Applied)
+	}
+	if got := theirs.GetByName("Tryck_mat!").Ints(); !slices.Equal(got, []int{110, 155, 210, 120, 170, 220}) {
+		t.Fatalf("merged %v", got)
+	}
+	if len(res.Conflicts) != 1 || res.Conflicts[0].Symbol != "scalar" || res.Conflicts[0].Ours != 6 || res.Conflicts[0].Theirs != 7 {
+		t.Fatalf("conflicts %+v", res.Conflicts)
+	}
+	if theirs.GetByName("scalar").Ints()[0] != 7 {
+		t.Fatal("conflicting cell overwritten")
+	}
+	if len(res.Flagged) != 1 || res.Flagged[0].Symbol != "grown" {
+		t.Fatalf("flagged %+v", res.Flagged)
+	}
+	if n := len(theirs.Changes()); n != 1 {
+		t.Fatalf("%d journal entries, want 1", n)
+	}
+}
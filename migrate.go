@@ -0,0 +1,150 @@
+package symbol
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// MigrationReport sorts every symbol both files have by how it was carried
+// over.
+type MigrationReport struct {
+	Exact     []string          `json:"exact,omitempty"`     // same values, possibly rescaled
+	Resampled []string          `json:"resampled,omitempty"` // interpolated onto the target's axes
+	Skipped   []MergeFlag       `json:"skipped,omitempty"`
+	Saturated map[string][]Cell `json:"saturated,omitempty"` // cells the target type could not hold
+}
+
+// Migrate carries the calibration of src over to dst, a different software
+// version, by symbol name and in physical units. Axes go first: one with the
+// same number of breakpoints is copied, one without keeps dst's breakpoints.
+// A map is then copied as is where its shape and breakpoints match, and
+// otherwise resampled through src's interpolation at dst's breakpoints, each
+// side looked up in its own file's layout. Anything else with the same number
+// of values is copied value by value; the rest is skipped.
+func Migrate(src, dst FirmwareFile) (*MigrationReport, error) {
+	rep := &MigrationReport{Saturated: make(map[string][]Cell)}
+	axes := make(map[string]bool)
+	for _, ax := range GetFileAxisCollection(dst) {
+		if ax.X != "" {
+			axes[ax.X] = true
+		}
+		if ax.Y != "" {
+			axes[ax.Y] = true
+		}
+	}
+	var first, then []*Symbol
+	for _, sd := range dst.Symbols() {
+		if src.GetByName(sd.Name) == nil {
+			continue
+		}
+		if axes[sd.Name] {
+			first = append(first, sd)
+		} else {
+			then = append(then, sd)
+		}
+	}
+
+	for _, sd := range append(first, then...) {
+		ss := src.GetByName(sd.Name)
+		if len(ss.data) == 0 || len(sd.data) == 0 {
+			rep.skip(sd.Name, "no data")
+			continue
+		}
+		if ax := GetFileInfo(dst, sd.Name); !axes[sd.Name] && (ax.X != "" || ax.Y != "") {
+			ms, errS := NewMap(src, sd.Name)
+			md, errD := NewMap(dst, sd.Name)
+			if errS == nil && errD == nil && ms.z == ss && md.z == sd {
+				if err := rep.migrateMap(ms, md); err != nil {
+					return rep, err
+				}
+				continue
+			}
+		}
+		if err := rep.migrateValues(ss, sd); err != nil {
+			return rep, err
+		}
+	}
+	sort.Strings(rep.Exact)
+	sort.Strings(rep.Resampled)
+	return rep, nil
+}
+
+func (rep *MigrationReport) skip(name, format string, args ...any) {
+	rep.Skipped = append(rep.Skipped, MergeFlag{Symbol: name, Reason: fmt.Sprintf(format, args...)})
+}
+
+func (rep *MigrationReport) migrateMap(ms, md *Map) error {
+	z := make([][]float64, md.Rows())
+	exact := sameBreakpoints(ms.X, md.X) && sameBreakpoints(ms.Y, md.Y)
+	for r := range z {
+		z[r] = make([]float64, md.Cols())
+		for c := range z[r] {
+			if exact {
+				z[r][c] = ms.Z[r][c]
+			} else {
+				z[r][c] = ms.Lookup(md.X[c], md.Y[r])
+			}
+		}
+	}
+	saturated, err := md.store(z)
+	if err != nil {
+		return err
+	}
+	if len(saturated) > 0 {
+		rep.Saturated[md.Name] = saturated
+	}
+	if exact {
+		rep.Exact = append(rep.Exact, md.Name)
+	} else {
+		rep.Resampled = append(rep.Resampled, md.Name)
+	}
+	return nil
+}
+
+// migrateValues copies a symbol that is not a map, or not one both sides can
+// build, value by value.
+func (rep *MigrationReport) migrateValues(ss, sd *Symbol) error {
+	if ss.Type == sd.Type && ss.Correctionfactor == sd.Correctionfactor && ss.offset() == sd.offset() && len(ss.data) == len(sd.data) {
+		if !bytes.Equal(ss.data, sd.data) {
+			if err := sd.SetData(bytes.Clone(ss.data)); err != nil {
+				return err
+			}
+		}
+		rep.Exact = append(rep.Exact, sd.Name)
+		return nil
+	}
+	values := ss.Float64s()
+	if len(values) != len(sd.Ints()) {
+		rep.skip(sd.Name, "%d values in source, %d in target", len(values), len(sd.Ints()))
+		return nil
+	}
+	if sd.Correctionfactor == 0 {
+		rep.skip(sd.Name, "target has no correction factor")
+		return nil
+	}
+	m := &Map{Name: sd.Name, X: make([]float64, len(values)), Y: []float64{0}, z: sd}
+	saturated, err := m.store([][]float64{values})
+	if err != nil {
+		return err
+	}
+	if len(saturated) > 0 {
+		rep.Saturated[sd.Name] = saturated
+	}
+	rep.Exact = append(rep.Exact, sd.Name)
+	return nil
+}
+
+// sameBreakpoints compares two axes to well within a raw step.
+func sameBreakpoints(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if math.Abs(a[i]-b[i]) > 1e-6*math.Max(1, math.Abs(a[i])) {
+			return false
+		}
+	}
+	return true
+}
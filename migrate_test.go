@@ -0,0 +1,56 @@
+package symbol
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestMigrate(t *testing.T) {
+	src := &T7File{Collection: NewCollection(
+		testSymbol("BFuelCal.AirXSP", 0, 1, 100, 200, 300),
+		testSymbol("BFuelCal.RpmYSP", 0, 1, 1000, 2000),
+		testSymbol("BFuelCal.Map", 0, 1, 10, 20, 30, 40, 60, 80),
+		testSymbol("BFuelCal.E85Map", 0, 1, 1, 2, 3, 4, 5, 6),
+		testSymbol("scalar", 0, 0.1, 25),
+		testSymbol("table", 0, 1, 1, 2, 3),
+		testSymbol("shifted", 0, 1, 50),
+	)}
+	dst := &T7File{Collection: NewCollection(
+		testSymbol("BFuelCal.AirXSP", 0, 1, 100, 150, 250, 300),
+		testSymbol("BFuelCal.RpmYSP", 0, 1, 0, 0),
+		testSymbol("BFuelCal.Map", 0, 1, 0, 0, 0, 0, 0, 0, 0, 0),
+		testSymbol("scalar", 0, 0.5, 0),
+		testSymbol("table", 0, 1, 1, 2),
+		testSymbol("shifted", 0, 1, 0),
+	)}
+	dst.GetByName("shifted").Offset = -40
+
+	rep, err := Migrate(src, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(rep.Exact, []string{"BFuelCal.RpmYSP", "scalar", "shifted"}) || !slices.Equal(rep.Resampled, []string{"BFuelCal.Map"}) {
+		t.Fatalf("exact %v, resampled %v", rep.Exact, rep.Resampled)
+	}
+	var skipped []string
+	for _, s := range rep.Skipped {
+		skipped = append(skipped, s.Symbol)
+	}
+	slices.Sort(skipped)
+	// AirXSP has a breakpoint more in the target, so it keeps its own.
+	if !slices.Equal(skipped, []string{"BFuelCal.AirXSP", "table"}) {
+		t.Fatalf("skipped %v", rep.Skipped)
+	}
+
+	if got := dst.GetByName("BFuelCal.Map").Ints(); !slices.Equal(got, []int{10, 15, 25, 30, 40, 50, 70, 80}) {
+		t.Fatalf("resampled map %v", got)
+	}
+	if got := dst.GetByName("scalar").Float64s()[0]; math.Abs(got-2.5) > 1e-9 {
+		t.Fatalf("scalar %g, want 2.5", got)
+	}
+	// Same type and factor but another offset is not a byte copy.
+	if got := dst.GetByName("shifted").Ints()[0]; got != 90 {
+		t.Fatalf("shifted raw %d, want 90", got)
+	}
+}
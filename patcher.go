@@ -113,10 +113,10 @@ type SymbolUpdate struct {
 func (su *SymbolUpdate) Apply(sc FirmwareFile) error {
 	sym := sc.GetByName(su.SymbolName)
 	if sym == nil {
-		return fmt.Errorf("symbol %s not found", su.SymbolName)
+		return &SymbolError{ECU: ECU_UNKNOWN, Name: su.SymbolName, Err: ErrSymbolNotFound}
 	}
 	if len(su.Data) != su.Length {
-		return fmt.Errorf("length of data (%d) does not match length (%d)", len(su.Data), su.Length)
+		return &SymbolError{ECU: ECU_UNKNOWN, Name: su.SymbolName, Address: sym.Address, Expected: su.Length, Actual: len(su.Data), Err: ErrDataLength}
 	}
 	if err := sym.SetData(su.Data); err != nil {
 		return err
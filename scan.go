@@ -0,0 +1,291 @@
+package symbol
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ScanResult is what loading one file of a corpus reported.
+type ScanResult struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+	SHA256  string    `json:"sha256"`
+
+	ECU       string         `json:"ecu"`
+	Version   string         `json:"version,omitempty"`
+	Checksums []ScanChecksum `json:"checksums,omitempty"`
+	Footer    []string       `json:"footer,omitempty"` // T7 footer repairs Load made
+	Symbols   int            `json:"symbols"`
+	Names     NameSource     `json:"names,omitempty"`
+	Warnings  []string       `json:"warnings,omitempty"`
+	Error     string         `json:"error,omitempty"`
+}
+
+type ScanChecksum struct {
+	Area  string `json:"area"`
+	Valid bool   `json:"valid"`
+}
+
+// ChecksumOK reports whether every checksum area was valid as stored.
+func (r *ScanResult) ChecksumOK() bool {
+	for _, c := range r.Checksums {
+		if !c.Valid {
+			return false
+		}
+	}
+	return len(r.Checksums) > 0
+}
+
+type ScanOpt func(*scanConfig)
+
+type scanConfig struct {
+	workers  int
+	match    func(path string) bool
+	previous map[string]ScanResult
+	progress func(ScanResult)
+}
+
+// WithScanWorkers bounds how many files are loaded at once, GOMAXPROCS by
+// default.
+func WithScanWorkers(n int) ScanOpt {
+	return func(c *scanConfig) {
+		c.workers = n
+	}
+}
+
+// WithScanMatch picks the files to load, by default those ending in .bin.
+func WithScanMatch(f func(path string) bool) ScanOpt {
+	return func(c *scanConfig) {
+		c.match = f
+	}
+}
+
+// WithScanPrevious resumes from an earlier report: a file with the same path,
+// size and modification time is not loaded again and keeps its old result.
+func WithScanPrevious(results []ScanResult) ScanOpt {
+	return func(c *scanConfig) {
+		for _, r := range results {
+			c.previous[r.Path] = r
+		}
+	}
+}
+
+// WithScanProgress is called with every fresh result as it comes in, from one
+// goroutine at a time, so a report can be appended to while the scan runs.
+func WithScanProgress(f func(ScanResult)) ScanOpt {
+	return func(c *scanConfig) {
+		c.progress = f
+	}
+}
+
+// Scan walks root and loads every matching file with Load. The results, sorted
+// by path, include the ones WithScanPrevious carried over. A cancelled ctx
+// stops the scan between files and returns what was done so far with its
+// error.
+func Scan(ctx context.Context, root string, opts ...ScanOpt) ([]ScanResult, error) {
+	cfg := scanConfig{
+		workers: runtime.GOMAXPROCS(0),
+		match: func(path string) bool {
+			return strings.EqualFold(filepath.Ext(path), ".bin")
+		},
+		previous: make(map[string]ScanResult),
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	cfg.workers = max(cfg.workers, 1)
+
+	var results []ScanResult
+	var todo []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			results = append(results, ScanResult{Path: path, Error: err.Error()})
+			if d != nil && d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || !cfg.match(path) {
+			return nil
+		}
+		if prev, ok := cfg.previous[path]; ok {
+			if info, err := d.Info(); err == nil && info.Size() == prev.Size && info.ModTime().Equal(prev.ModTime) {
+				results = append(results, prev)
+				return nil
+			}
+		}
+		todo = append(todo, path)
+		return nil
+	})
+	if err != nil {
+		return results, err
+	}
+
+	paths := make(chan string)
+	done := make(chan ScanResult)
+	var wg sync.WaitGroup
+	for range min(cfg.workers, max(len(todo), 1)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range paths {
+				done <- scanFile(path)
+			}
+		}()
+	}
+	go func() {
+		defer close(paths)
+		for _, path := range todo {
+			select {
+			case paths <- path:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	for r := range done {
+		if cfg.progress != nil {
+			cfg.progress(r)
+		}
+		results = append(results, r)
+	}
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Path < results[j].Path })
+	return results, ctx.Err()
+}
+
+// scanFile loads one file. The loaders parse untrusted input, so a panic is
+// reported as that file's error rather than taking the scan down.
+func scanFile(path string) (r ScanResult) {
+	r = ScanResult{Path: path, ECU: ECU_UNKNOWN.String()}
+	defer func() {
+		if p := recover(); p != nil {
+			r.Error = fmt.Sprintf("panic: %v", p)
+		}
+	}()
+	info, err := os.Stat(path)
+	if err != nil {
+		r.Error = err.Error()
+		return r
+	}
+	r.Size, r.ModTime = info.Size(), info.ModTime()
+	if r.Size > MaxFileLength {
+		r.Error = ErrToLarge.Error()
+		return r
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		r.Error = err.Error()
+		return r
+	}
+	sum := sha256.Sum256(data)
+	r.SHA256 = hex.EncodeToString(sum[:])
+
+	ecu, fw, err := Load(path, data, func(string) {}, WithLoadEventFunc(func(e LoadEvent) {
+		switch e.Kind {
+		case EventChecksum:
+			r.Checksums = append(r.Checksums, ScanChecksum{Area: e.Area, Valid: e.Valid})
+		case EventFooterRepair:
+			r.Footer = append(r.Footer, e.Message)
+		case EventNameSource:
+			r.Names = e.Source
+		case EventWarning:
+			r.Warnings = append(r.Warnings, e.Message)
+		}
+	}))
+	r.ECU = ecu.String()
+	if err != nil {
+		r.Error = err.Error()
+		// T5 and T7 come back loaded when only the checksum is wrong.
+		if !errors.Is(err, ErrChecksumMismatch) || fw == nil {
+			return r
+		}
+	}
+	r.Version = fw.Version()
+	r.Symbols = fw.Count()
+	return r
+}
+
+// WriteScanJSON writes one result per line, the form ReadScanJSON reads back
+// to resume from.
+func WriteScanJSON(w io.Writer, results ...ScanResult) error {
+	enc := json.NewEncoder(w)
+	for _, r := range results {
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadScanJSON reads a report written by WriteScanJSON. A line cut short by an
+// interrupted scan is skipped; that file is simply scanned again.
+func ReadScanJSON(r io.Reader) ([]ScanResult, error) {
+	var out []ScanResult
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 1<<20)
+	for sc.Scan() {
+		var res ScanResult
+		if err := json.Unmarshal(sc.Bytes(), &res); err != nil {
+			continue
+		}
+		out = append(out, res)
+	}
+	return out, sc.Err()
+}
+
+var scanCSVHeader = []string{"path", "size", "modified", "sha256", "ecu", "version", "checksum", "footer", "symbols", "names", "warnings", "error"}
+
+// WriteScanCSV writes the report as a spreadsheet. Checksum is "ok", "bad:"
+// followed by the failing areas, or empty when nothing was verified.
+func WriteScanCSV(w io.Writer, results []ScanResult) error {
+	cw := csv.NewWriter(w)
+	cw.Write(scanCSVHeader)
+	for _, r := range results {
+		checksum := ""
+		if len(r.Checksums) > 0 {
+			checksum = "ok"
+			var bad []string
+			for _, c := range r.Checksums {
+				if !c.Valid {
+					bad = append(bad, c.Area)
+				}
+			}
+			if len(bad) > 0 {
+				checksum = "bad:" + strings.Join(bad, " ")
+			}
+		}
+		modified := ""
+		if !r.ModTime.IsZero() {
+			modified = r.ModTime.UTC().Format(time.RFC3339)
+		}
+		cw.Write([]string{
+			r.Path, strconv.FormatInt(r.Size, 10), modified, r.SHA256, r.ECU, r.Version, checksum,
+			strings.Join(r.Footer, "; "), strconv.Itoa(r.Symbols), string(r.Names),
+			strings.Join(r.Warnings, "; "), r.Error,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
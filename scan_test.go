@@ -0,0 +1,76 @@
+package symbol
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestScan(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, data []byte) {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a/tcm.bin", aw55TestImage())
+	write("a/b/copy.BIN", aw55TestImage())
+	write("junk.bin", []byte("not firmware"))
+	write("notes.txt", []byte("ignored"))
+
+	var fresh int
+	results, err := Scan(context.Background(), dir, WithScanWorkers(2), WithScanProgress(func(ScanResult) { fresh++ }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 3 || fresh != 3 {
+		t.Fatalf("%d results, %d fresh: %+v", len(results), fresh, results)
+	}
+	tcm := results[1]
+	if !strings.HasSuffix(tcm.Path, "tcm.bin") || tcm.ECU != "AW55" || tcm.Symbols == 0 || !tcm.ChecksumOK() || tcm.Names != NamesDefinition || tcm.Error != "" {
+		t.Fatalf("tcm %+v", tcm)
+	}
+	if results[0].SHA256 != tcm.SHA256 {
+		t.Fatalf("identical files hash differently")
+	}
+	if junk := results[2]; junk.ECU != "Unknown" || junk.Error == "" {
+		t.Fatalf("junk %+v", junk)
+	}
+
+	var report bytes.Buffer
+	if err := WriteScanJSON(&report, results...); err != nil {
+		t.Fatal(err)
+	}
+	report.WriteString(`{"path": "cut short`)
+	previous, err := ReadScanJSON(&report)
+	if err != nil || len(previous) != 3 {
+		t.Fatalf("read back %d: %v", len(previous), err)
+	}
+
+	write("junk.bin", []byte("changed, not firmware"))
+	fresh = 0
+	resumed, err := Scan(context.Background(), dir, WithScanPrevious(previous), WithScanProgress(func(r ScanResult) {
+		fresh++
+		if !strings.HasSuffix(r.Path, "junk.bin") {
+			t.Errorf("%s scanned again", r.Path)
+		}
+	}))
+	if err != nil || len(resumed) != 3 || fresh != 1 {
+		t.Fatalf("resumed %d, %d fresh: %v", len(resumed), fresh, err)
+	}
+
+	var csv bytes.Buffer
+	if err := WriteScanCSV(&csv, resumed); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(csv.String(), "\n"); lines != 4 || !strings.Contains(csv.String(), ",AW55,") {
+		t.Fatalf("csv:\n%s", csv.String())
+	}
+}
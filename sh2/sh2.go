@@ -0,0 +1,164 @@
+// Package sh2 decodes the part of the Hitachi SH-2 instruction set that is
+// needed to follow constants into function calls: immediates, PC-relative
+// literal loads, register moves, GBR-relative loads, stack stores and pops,
+// and the call and branch instructions. Everything else decodes as Other, with Rn set
+// to the register the instruction would write if it writes one in the common
+// n-field position, so a caller can forget what it knew about it.
+package sh2
+
+// Op is a decoded operation.
+type Op int
+
+const (
+	Other     Op = iota
+	MovImm       // mov #imm,Rn
+	MovLPC       // mov.l @(disp,PC),Rn   Addr = literal address
+	MovWPC       // mov.w @(disp,PC),Rn   Addr = literal address
+	Mova         // mova @(disp,PC),R0    Addr = effective address
+	Mov          // mov Rm,Rn
+	ExtuB        // extu.b Rm,Rn
+	ExtuW        // extu.w Rm,Rn
+	ExtsB        // exts.b Rm,Rn
+	ExtsW        // exts.w Rm,Rn
+	AddImm       // add #imm,Rn
+	MovGBR       // mov.{b,w,l} @(disp,GBR),R0  Imm = byte offset from GBR, Size = 1, 2, 4
+	Push         // mov.{b,w,l} Rm,@-Rn   Size = 1, 2, 4
+	Pop          // mov.{b,w,l} @Rm+,Rn   Size = 1, 2, 4; Rm advances by Size unless it is Rn
+	StoreDisp    // mov.l Rm,@(disp,Rn)   Base = n, Imm = byte offset
+	Jsr          // jsr @Rn
+	Jmp          // jmp @Rn
+	Bsr          // bsr disp              Addr = target
+	Bra          // bra disp              Addr = target
+	Bsrf         // bsrf Rn
+	Braf         // braf Rn
+	Rts          // rts
+	Rte          // rte
+	Bts          // bt/s disp             Addr = target
+	Bfs          // bf/s disp             Addr = target
+)
+
+// Inst is one decoded instruction. Rn is -1 when the instruction writes no
+// general register.
+type Inst struct {
+	Op   Op
+	Rn   int
+	Rm   int
+	Base int // address register of a store
+	Imm  int32
+	Size int
+	Addr uint32
+}
+
+// HasDelaySlot reports whether the next instruction executes before this one
+// takes effect.
+func (i Inst) HasDelaySlot() bool {
+	switch i.Op {
+	case Jsr, Jmp, Bsr, Bra, Bsrf, Braf, Rts, Rte, Bts, Bfs:
+		return true
+	}
+	return false
+}
+
+// Decode decodes the instruction word w found at address pc.
+func Decode(pc uint32, w uint16) Inst {
+	n := int(w>>8) & 0xF
+	m := int(w>>4) & 0xF
+	d8 := uint32(w & 0xFF)
+	switch w & 0xF000 {
+	case 0xE000:
+		return Inst{Op: MovImm, Rn: n, Imm: int32(int8(w))}
+	case 0xD000:
+		return Inst{Op: MovLPC, Rn: n, Size: 4, Addr: pc&^3 + 4 + d8*4}
+	case 0x9000:
+		return Inst{Op: MovWPC, Rn: n, Size: 2, Addr: pc + 4 + d8*2}
+	case 0x7000:
+		return Inst{Op: AddImm, Rn: n, Imm: int32(int8(w))}
+	case 0xA000, 0xB000:
+		disp := int32(w&0xFFF) << 20 >> 20
+		i := Inst{Op: Bra, Rn: -1, Addr: uint32(int32(pc) + 4 + disp*2)}
+		if w&0xF000 == 0xB000 {
+			i.Op = Bsr
+		}
+		return i
+	case 0x1000:
+		return Inst{Op: StoreDisp, Rn: -1, Rm: m, Base: n, Imm: int32(w&0xF) * 4, Size: 4}
+	case 0x2000:
+		switch w & 0xF {
+		case 0x4, 0x5, 0x6:
+			return Inst{Op: Push, Rn: n, Rm: m, Size: 1 << (w&0xF - 4)}
+		case 0x9, 0xA, 0xB, 0xD:
+			return Inst{Op: Other, Rn: n} // and, xor, or, xtrct
+		}
+		return Inst{Op: Other, Rn: -1}
+	case 0x6000:
+		switch w & 0xF {
+		case 0x3:
+			return Inst{Op: Mov, Rn: n, Rm: m}
+		case 0xC:
+			return Inst{Op: ExtuB, Rn: n, Rm: m}
+		case 0xD:
+			return Inst{Op: ExtuW, Rn: n, Rm: m}
+		case 0xE:
+			return Inst{Op: ExtsB, Rn: n, Rm: m}
+		case 0xF:
+			return Inst{Op: ExtsW, Rn: n, Rm: m}
+		case 0x4, 0x5, 0x6:
+			return Inst{Op: Pop, Rn: n, Rm: m, Size: 1 << (w&0xF - 4)}
+		}
+		return Inst{Op: Other, Rn: n}
+	case 0xC000:
+		switch w & 0xFF00 {
+		case 0xC400:
+			return Inst{Op: MovGBR, Rn: 0, Imm: int32(d8), Size: 1}
+		case 0xC500:
+			return Inst{Op: MovGBR, Rn: 0, Imm: int32(d8) * 2, Size: 2}
+		case 0xC600:
+			return Inst{Op: MovGBR, Rn: 0, Imm: int32(d8) * 4, Size: 4}
+		case 0xC700:
+			return Inst{Op: Mova, Rn: 0, Addr: pc&^3 + 4 + d8*4}
+		case 0xC900, 0xCA00, 0xCB00:
+			return Inst{Op: Other, Rn: 0} // and/xor/or #imm,R0
+		}
+		return Inst{Op: Other, Rn: -1} // GBR stores, trapa, tst and the .b memory ops
+	case 0x4000:
+		switch w & 0xFF {
+		case 0x0B:
+			return Inst{Op: Jsr, Rn: -1, Rm: n}
+		case 0x2B:
+			return Inst{Op: Jmp, Rn: -1, Rm: n}
+		}
+		return Inst{Op: Other, Rn: n}
+	case 0x0000:
+		switch {
+		case w == 0x000B:
+			return Inst{Op: Rts, Rn: -1}
+		case w == 0x002B:
+			return Inst{Op: Rte, Rn: -1}
+		case w&0xFF == 0x03:
+			return Inst{Op: Bsrf, Rn: -1, Rm: n}
+		case w&0xFF == 0x23:
+			return Inst{Op: Braf, Rn: -1, Rm: n}
+		case w&0xF == 0x2, w&0xF == 0xA, w&0xFF == 0x29, w&0xF >= 0xC:
+			// stc, sts, movt, mov.{b,w,l} @(R0,Rm),Rn and mac.l
+			return Inst{Op: Other, Rn: n}
+		}
+		// nop, clrt, sett, clrmac, sleep, div0u, mul.l and the stores
+		return Inst{Op: Other, Rn: -1}
+	case 0x8000:
+		// mov.{b,w} R0,@(disp,Rn) and the conditional branches write nothing,
+		// mov.{b,w} @(disp,Rm),R0 and cmp/eq #imm,R0 at most R0.
+		switch w & 0xFF00 {
+		case 0x8400, 0x8500:
+			return Inst{Op: Other, Rn: 0}
+		case 0x8D00, 0x8F00:
+			i := Inst{Op: Bts, Rn: -1, Addr: uint32(int32(pc) + 4 + int32(int8(w))*2)}
+			if w&0xFF00 == 0x8F00 {
+				i.Op = Bfs
+			}
+			return i
+		}
+		return Inst{Op: Other, Rn: -1}
+	}
+	// 0x3xxx arithmetic and 0x5xxx mov.l @(disp,Rm),Rn write Rn.
+	return Inst{Op: Other, Rn: n}
+}
@@ -0,0 +1,36 @@
+package sh2
+
+import "testing"
+
+func TestDecode(t *testing.T) {
+	for _, c := range []struct {
+		name  string
+		pc    uint32
+		w     uint16
+		want  Inst
+		delay bool
+	}{
+		{"nop", 0x1000, 0x0009, Inst{Op: Other, Rn: -1}, false},
+		{"clrt", 0x1000, 0x0008, Inst{Op: Other, Rn: -1}, false},
+		{"sts macl,r3", 0x1000, 0x031A, Inst{Op: Other, Rn: 3}, false},
+		{"mov.l @(r0,r2),r5", 0x1000, 0x052E, Inst{Op: Other, Rn: 5}, false},
+		{"rts", 0x1000, 0x000B, Inst{Op: Rts, Rn: -1}, true},
+		{"rte", 0x1000, 0x002B, Inst{Op: Rte, Rn: -1}, true},
+		{"bt/s +4", 0x1000, 0x8D02, Inst{Op: Bts, Rn: -1, Addr: 0x1008}, true},
+		{"bf/s -2", 0x1000, 0x8FFF, Inst{Op: Bfs, Rn: -1, Addr: 0x1002}, true},
+		{"bt", 0x1000, 0x8902, Inst{Op: Other, Rn: -1}, false},
+		{"mov.l @r15+,r4", 0x1000, 0x64F6, Inst{Op: Pop, Rn: 4, Rm: 15, Size: 4}, false},
+		{"mov.b @r2+,r1", 0x1000, 0x6124, Inst{Op: Pop, Rn: 1, Rm: 2, Size: 1}, false},
+		{"mov.l r4,@-r15", 0x1000, 0x2F46, Inst{Op: Push, Rn: 15, Rm: 4, Size: 4}, false},
+		{"mov.l @(8,pc),r1", 0x1002, 0xD102, Inst{Op: MovLPC, Rn: 1, Size: 4, Addr: 0x100C}, false},
+		{"jsr @r3", 0x1000, 0x430B, Inst{Op: Jsr, Rn: -1, Rm: 3}, true},
+	} {
+		got := Decode(c.pc, c.w)
+		if got != c.want {
+			t.Errorf("%s: %+v, want %+v", c.name, got, c.want)
+		}
+		if got.HasDelaySlot() != c.delay {
+			t.Errorf("%s: delay slot %v, want %v", c.name, got.HasDelaySlot(), c.delay)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package symbol
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// StockLibrary is a directory of stock binaries. Each is stored under its
+// Version() and fingerprinted into stockIndex, so a file is matched to its
+// stock origin by version first and by code when the version was edited.
+type StockLibrary struct {
+	dir   string
+	index *FingerprintDB
+}
+
+const stockIndex = "stock.json"
+
+// StockMinSimilarity is how much of its code a file has to share with a stock
+// entry to be taken as built from it.
+const StockMinSimilarity = 0.9
+
+// OpenStockLibrary opens the library in dir, creating the directory if needed.
+func OpenStockLibrary(dir string) (*StockLibrary, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	index, err := LoadFingerprintDB(filepath.Join(dir, stockIndex))
+	if err != nil {
+		return nil, err
+	}
+	return &StockLibrary{dir: dir, index: index}, nil
+}
+
+// Entries are the fingerprints of the stock files in the library.
+func (l *StockLibrary) Entries() []*Fingerprint {
+	return l.index.Entries
+}
+
+// Add copies a stock binary into the library, replacing one of the same
+// version. A bin without a version is stored under its SHA-256.
+func (l *StockLibrary) Add(data []byte) (*Fingerprint, error) {
+	_, fw, err := Load("", data, func(string) {})
+	if err != nil {
+		return nil, err
+	}
+	name := stockName(fw.Version())
+	if name == "" {
+		sum := sha256.Sum256(data)
+		name = hex.EncodeToString(sum[:8])
+	}
+	name += ".bin"
+	fp, err := NewFingerprint(name, fw)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(l.dir, name), data, 0o644); err != nil {
+		return nil, err
+	}
+	l.index.Add(fp)
+	return fp, l.index.Save(filepath.Join(l.dir, stockIndex))
+}
+
+// stockName makes a version string usable as a file name.
+func stockName(version string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '.':
+			return r
+		case r <= ' ' || r == 0x7F:
+			return -1
+		}
+		return '_'
+	}, strings.TrimSpace(version))
+}
+
+// Stock is the stock file a firmware was derived from, ready to copy
+// calibration back out of.
+type Stock struct {
+	File  FirmwareFile
+	Match FingerprintMatch
+	fw    FirmwareFile
+}
+
+// StockFor finds the stock origin of fw: an entry of the same version if its
+// code matches, otherwise the closest code at StockMinSimilarity or better.
+func (l *StockLibrary) StockFor(fw FirmwareFile) (*Stock, error) {
+	fp, err := NewFingerprint("", fw)
+	if err != nil {
+		return nil, err
+	}
+	matches := l.index.Lookup(fp)
+	best := -1
+	for i, m := range matches {
+		if m.Similarity < StockMinSimilarity {
+			break
+		}
+		if best < 0 {
+			best = i
+		}
+		if m.Entry.Version == fp.Version {
+			best = i
+			break
+		}
+	}
+	if best < 0 {
+		return nil, ErrStockNotFound
+	}
+	m := matches[best]
+	data, err := os.ReadFile(filepath.Join(l.dir, m.Entry.Name))
+	if err != nil {
+		return nil, err
+	}
+	_, stock, err := Load(m.Entry.Name, data, func(string) {})
+	if err != nil && (!errors.Is(err, ErrChecksumMismatch) || stock == nil) {
+		return nil, err
+	}
+	return &Stock{File: stock, Match: m, fw: fw}, nil
+}
+
+// RevertSymbol copies the stock data of name into the file StockFor was
+// given. It goes through SetData, so a journal can undo it.
+func (s *Stock) RevertSymbol(name string) error {
+	sym := s.fw.GetByName(name)
+	if sym == nil {
+		return &SymbolError{ECU: ecuOf(s.fw), Name: name, Err: ErrSymbolNotFound}
+	}
+	_, err := s.revert(sym)
+	return err
+}
+
+// RevertAll reverts every symbol filter accepts, all of them for a nil filter,
+// and returns the names that differed from stock. Symbols the stock file does
+// not have are left alone; ones whose size differs are reported in the error
+// after the rest have been reverted.
+func (s *Stock) RevertAll(filter func(*Symbol) bool) ([]string, error) {
+	var reverted []string
+	var errs []error
+	for _, sym := range s.fw.Symbols() {
+		if filter != nil && !filter(sym) || s.File.GetByName(sym.Name) == nil {
+			continue
+		}
+		changed, err := s.revert(sym)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if changed {
+			reverted = append(reverted, sym.Name)
+		}
+	}
+	return reverted, errors.Join(errs...)
+}
+
+func (s *Stock) revert(sym *Symbol) (bool, error) {
+	stock := s.File.GetByName(sym.Name)
+	if stock == nil {
+		return false, &SymbolError{ECU: ecuOf(s.File), Name: sym.Name, Err: ErrSymbolNotFound}
+	}
+	if stock.Length != sym.Length {
+		return false, &SymbolError{ECU: ecuOf(s.fw), Name: sym.Name, Address: sym.Address, Expected: int(sym.Length), Actual: int(stock.Length), Err: ErrDataLength}
+	}
+	if bytes.Equal(stock.data, sym.data) {
+		return false, nil
+	}
+	return true, sym.SetData(bytes.Clone(stock.data))
+}
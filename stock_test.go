@@ -0,0 +1,69 @@
+package symbol
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestStockLibrary(t *testing.T) {
+	withCode := func(seed int64) []byte {
+		data := aw55TestImage()
+		rand.New(rand.NewSource(seed)).Read(data[aw55BootEnd:0x20000])
+		return data
+	}
+	dir := t.TempDir()
+	lib, err := OpenStockLibrary(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, seed := range []int64{1, 2} {
+		if _, err := lib.Add(withCode(seed)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if lib, err = OpenStockLibrary(dir); err != nil || len(lib.Entries()) != 2 {
+		t.Fatalf("reopened with %v, %v", lib, err)
+	}
+
+	fw, err := NewAW55File(withCode(1), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := fw.GetByName("Symbol-0")
+	want := bytes.Clone(m.Bytes())
+	m.SetData(m.EncodeInts([]int{1, 2, 3, 4, 5}))
+	y := fw.GetByName("Curve-7E000")
+	y.SetData(y.EncodeInts([]int{150, 300}))
+
+	stock, err := lib.StockFor(fw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stock.Match.Similarity != 1 || !slices.Equal(stock.Match.Changed, []string{"Curve-7E000", "Symbol-0"}) {
+		t.Fatalf("match %+v", stock.Match)
+	}
+	if err := stock.RevertSymbol("Symbol-0"); err != nil || !bytes.Equal(m.Bytes(), want) {
+		t.Fatalf("Symbol-0 = % X, %v", m.Bytes(), err)
+	}
+	if err := stock.RevertSymbol("Nope"); !errors.Is(err, ErrSymbolNotFound) {
+		t.Fatalf("unknown symbol: %v", err)
+	}
+	reverted, err := stock.RevertAll(func(s *Symbol) bool { return s.Name != "Symbol-1" })
+	if err != nil || !slices.Equal(reverted, []string{"Curve-7E000"}) {
+		t.Fatalf("reverted %v, %v", reverted, err)
+	}
+	if got := y.Ints(); got[0] != 100 {
+		t.Fatalf("curve still %v", got)
+	}
+
+	foreign, err := NewAW55File(withCode(3), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := lib.StockFor(foreign); !errors.Is(err, ErrStockNotFound) {
+		t.Fatalf("foreign code: %v", err)
+	}
+}
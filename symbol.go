@@ -22,14 +22,44 @@ type Symbol struct {
 	Type             uint8
 	ExtendedType     uint8
 	Correctionfactor float64
-	Unit             string `json:",omitempty"`
+	// Offset is added after Correctionfactor. The T5 loader fills it in from
+	// T5Offsets.
+	Offset float64 `json:",omitempty"`
+	Unit   string  `json:",omitempty"`
+
+	journal *journal // of the Collection holding it, records SetData
+}
+
+// LoadOpt configures Load beyond the printFunc every loader takes.
+type LoadOpt func(*loadConfig)
+
+type loadConfig struct {
+	eventFunc func(LoadEvent)
 }
 
-func Load(filename string, data []byte, printFunc func(string)) (ECUType, FirmwareFile, error) {
+// WithLoadEventFunc receives typed load events (stages, checksum verdicts,
+// footer repairs, symbol counts, name source, warnings) next to printFunc.
+func WithLoadEventFunc(f func(LoadEvent)) LoadOpt {
+	return func(c *loadConfig) {
+		c.eventFunc = f
+	}
+}
+
+// Load detects the ECU type of data and loads it. A loader that fails without
+// a file gives a nil FirmwareFile, never a typed nil, so callers that go on
+// with a file loaded despite a bad checksum can check it against nil.
+func Load(filename string, data []byte, printFunc func(string), opts ...LoadOpt) (ECUType, FirmwareFile, error) {
+	cfg := loadConfig{eventFunc: noEvent}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	cfg.eventFunc(LoadEvent{Kind: EventStageStarted, Stage: StageDetect, ECU: ECU_UNKNOWN})
 	ecuType, err := DetectType(data)
 	if err != nil {
 		return ECU_UNKNOWN, nil, err
 	}
+	cfg.eventFunc(LoadEvent{Kind: EventStageFinished, Stage: StageDetect, ECU: ecuType})
 
 	printFunc(fmt.Sprintf("Loading %s", filepath.Base(filename)))
 
@@ -38,31 +68,46 @@ func Load(filename string, data []byte, printFunc func(string)) (ECUType, Firmwa
 		sym, err := NewT5File(
 			data,
 			WithT5PrintFunc(printFunc),
+			WithT5EventFunc(cfg.eventFunc),
 		)
+		if sym == nil {
+			return ECU_T5, nil, err
+		}
 		return ECU_T5, sym, err
 	case ECU_T7:
 		sym, err := NewT7File(data,
 			WithT7AutoFixFooter(),
 			WithT7PrintFunc(printFunc),
+			WithT7EventFunc(cfg.eventFunc),
 		)
+		if sym == nil {
+			return ECU_T7, nil, err
+		}
 		return ECU_T7, sym, err
 	case ECU_T8:
 		sym, err := NewT8File(data,
 			WithT8AutoCorrectChecksum(),
 			WithT8PrintFunc(printFunc),
+			WithT8EventFunc(cfg.eventFunc),
 		)
+		if sym == nil {
+			return ECU_T8, nil, err
+		}
 		return ECU_T8, sym, err
 	case ECU_AW55:
-		sym, err := NewAW55File(data, printFunc)
+		sym, err := NewAW55File(data, printFunc, WithAW55EventFunc(cfg.eventFunc))
 		return ECU_AW55, sym, err
 	default:
-		return -1, nil, fmt.Errorf("unknown file format: %s", filename)
+		return -1, nil, fmt.Errorf("%s: %w", filename, ErrUnknownFileType)
 	}
 }
 
 func (s *Symbol) SetData(data []byte) error {
 	if len(data) != int(s.Length) {
-		return fmt.Errorf("Symbol %s expected %d bytes, got %d", s.Name, s.Length, len(data))
+		return &SymbolError{ECU: ECU_UNKNOWN, Name: s.Name, Address: s.Address, Expected: int(s.Length), Actual: len(data), Err: ErrDataLength}
+	}
+	if s.journal != nil {
+		s.journal.record(s.Name, s.data, data)
 	}
 	s.data = data
 	return nil
@@ -81,11 +126,19 @@ func (s *Symbol) Read(r io.Reader) error {
 		return err
 	}
 	if n != int(s.Length) {
-		return fmt.Errorf("symbol expected %d bytes, got %d", s.Length, n)
+		return &SymbolError{ECU: ECU_UNKNOWN, Name: s.Name, Address: s.Address, Expected: int(s.Length), Actual: n, Err: ErrDataLength}
 	}
 	return nil
 }
 
+// offset is what is added to a scaled value, nothing for a nil symbol.
+func (s *Symbol) offset() float64 {
+	if s == nil {
+		return 0
+	}
+	return s.Offset
+}
+
 func (s *Symbol) Bytes() []byte {
 	return s.data
 }
@@ -163,7 +216,7 @@ func (s *Symbol) Int64() int64 {
 func (s *Symbol) Float64s() []float64 {
 	var floats []float64
 	for _, v := range s.Ints() {
-		floats = append(floats, (float64(v)*s.Correctionfactor)+T5Offsets[s.Name])
+		floats = append(floats, (float64(v)*s.Correctionfactor)+s.offset())
 	}
 	return floats
 }
@@ -285,7 +338,7 @@ func (s *Symbol) Int() int {
 func (s *Symbol) BytesToFloat64s(data []byte) []float64 {
 	var floats []float64
 	for _, v := range s.BytesToInts(data) {
-		floats = append(floats, (float64(v)*s.Correctionfactor)+T5Offsets[s.Name])
+		floats = append(floats, (float64(v)*s.Correctionfactor)+s.offset())
 	}
 	return floats
 }
@@ -382,8 +435,8 @@ func (s *Symbol) EncodeInt(input int) []byte {
 }
 
 func (s *Symbol) EncodeFloat64(v float64) []byte {
-	newValue := int(math.Round((v - T5Offsets[s.Name]) / s.Correctionfactor))
-	// log.Printf("(%f - %f) / %f = %d", v, T5Offsets[s.Name], s.Correctionfactor, newValue)
+	newValue := int(math.Round((v - s.offset()) / s.Correctionfactor))
+	// log.Printf("(%f - %f) / %f = %d", v, s.offset(), s.Correctionfactor, newValue)
 	return s.EncodeInt(newValue)
 }
 
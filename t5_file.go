@@ -23,7 +23,10 @@ type T5File struct {
 	numberOfSymbols           int
 	m_symboltablestartaddress int
 	printFunc                 func(string)
+	eventFunc                 func(LoadEvent)
 	softwareVersion           string
+	definition                *Definition
+	axes                      AxisInformation // the definition's, where axisT5 has none
 	*Collection
 }
 
@@ -36,6 +39,14 @@ func WithT5PrintFunc(f func(string)) T5FileOpt {
 	}
 }
 
+// WithT5EventFunc receives the typed counterpart of the printFunc messages.
+func WithT5EventFunc(f func(LoadEvent)) T5FileOpt {
+	return func(t5 *T5File) error {
+		t5.eventFunc = f
+		return nil
+	}
+}
+
 func NewT5File(data []byte, opts ...T5FileOpt) (*T5File, error) {
 	if len(data) != LengthT55 {
 		return nil, ErrInvalidLength
@@ -51,6 +62,7 @@ func NewT5File(data []byte, opts ...T5FileOpt) (*T5File, error) {
 		printFunc: func(s string) {
 			log.Println(s)
 		},
+		eventFunc: noEvent,
 	}
 
 	for _, opt := range opts {
@@ -63,11 +75,35 @@ func NewT5File(data []byte, opts ...T5FileOpt) (*T5File, error) {
 }
 
 func (t5 *T5File) init() (*T5File, error) {
+	t5.event(LoadEvent{Kind: EventStageStarted, Stage: StageSymbols})
 	if err := t5.parseData(); err != nil {
-		return nil, err
+		if t5.definition == nil {
+			return nil, err
+		}
+		t5.printFunc(fmt.Sprintf("No symbol table: %v", err))
 	}
+	if t5.definition != nil {
+		if err := t5.addDefinition(); err != nil {
+			return nil, err
+		}
+	}
+	t5.event(LoadEvent{Kind: EventStageFinished, Stage: StageSymbols})
 	t5.softwareVersion = t5.findSoftwareVersion()
-	return t5, t5.VerifyChecksum()
+
+	t5.event(LoadEvent{Kind: EventStageStarted, Stage: StageChecksum})
+	if err := t5.VerifyChecksum(); err != nil {
+		return t5, err
+	}
+	t5.event(LoadEvent{Kind: EventStageFinished, Stage: StageChecksum})
+	return t5, nil
+}
+
+func (t5 *T5File) event(e LoadEvent) {
+	if t5.eventFunc == nil {
+		return
+	}
+	e.ECU = ECU_T5
+	t5.eventFunc(e)
 }
 
 // findSoftwareVersion scans the binary for a software ID matching the
@@ -162,9 +198,16 @@ func (t5 *T5File) VerifyChecksum() error {
 		return err
 	}
 	storedChecksum := t5.getChecksum()
+	t5.event(LoadEvent{
+		Kind:     EventChecksum,
+		Area:     "ROM",
+		Valid:    checksum == storedChecksum,
+		Expected: be32(checksum),
+		Actual:   be32(storedChecksum),
+	})
 	if checksum != storedChecksum {
 		t5.printFunc(fmt.Sprintf("checksum: %X storedChecksum: %X\n", checksum, storedChecksum))
-		return ErrChecksumMismatch
+		return &ChecksumError{ECU: ECU_T5, Area: "ROM", Offset: dataLength - 4, Expected: be32(checksum), Actual: be32(storedChecksum)}
 	}
 	t5.printFunc(fmt.Sprintf("Checksum %X OK", checksum))
 	return nil
@@ -186,6 +229,12 @@ func (t5 *T5File) Save(filename string) error {
 	return os.WriteFile(filename, t5.data, 0o644)
 }
 
+// Bytes is the image as loaded, without the edits made since; Byte writes them
+// in first.
+func (t5 *T5File) Bytes() []byte {
+	return t5.data
+}
+
 func (t5 *T5File) Byte() ([]byte, error) {
 	for _, sym := range t5.Symbols() {
 		if sym.Address == 0 {
@@ -384,16 +433,49 @@ outer:
 	for _, v := range alh {
 		if !v.Used {
 			log.Printf("Unused address: %X", v.FlashAddress)
+			t5.event(LoadEvent{Kind: EventWarning, Message: fmt.Sprintf("unused address: %X", v.FlashAddress)})
 		}
 	}
 
 	t5.Add(symbols...)
 
 	t5.printFunc(fmt.Sprintf("Loaded %d symbols from binary", len(symbols)))
+	t5.event(LoadEvent{Kind: EventNameSource, Source: NamesPlain})
+	t5.event(LoadEvent{Kind: EventSymbolCount, Count: len(symbols)})
 
 	return nil
 }
 
+// addDefinition loads the symbols only the definition has.
+func (t5 *T5File) addDefinition() error {
+	symbols, alias, err := t5.definition.extra(t5.Collection, t5.data)
+	if err != nil {
+		return err
+	}
+	t5.Add(symbols...)
+	for name, native := range alias {
+		t5.event(LoadEvent{Kind: EventWarning, Message: fmt.Sprintf("definition symbol %s overlaps %s, using %s", name, native, native)})
+	}
+	t5.axes = make(AxisInformation)
+	for name, ax := range t5.definition.Axes {
+		name = aliased(alias, name)
+		if _, ok := axisT5[name]; ok {
+			continue
+		}
+		ax.X, ax.Y, ax.Z = aliased(alias, ax.X), aliased(alias, ax.Y), aliased(alias, ax.Z)
+		t5.axes[name] = ax
+	}
+	t5.printFunc(fmt.Sprintf("Loaded %d symbols from definition %s", len(symbols), t5.definition.Title))
+	t5.event(LoadEvent{Kind: EventNameSource, Source: NamesDefinition, Message: t5.definition.Title})
+	t5.event(LoadEvent{Kind: EventSymbolCount, Count: t5.Count()})
+	return nil
+}
+
+// axisInformation is what GetFileInfo answers from for t5.
+func (t5 *T5File) axisInformation() AxisInformation {
+	return t5.axes
+}
+
 type addressRecord struct {
 	FlashAddress uint32
 	Used         bool
@@ -693,5 +775,6 @@ func (t5 *T5File) tosymbol(data []byte) (*Symbol, error) {
 		Name:             name,
 		Type:             T5Types[name],
 		Correctionfactor: GetCorrectionfactor(name),
+		Offset:           T5Offsets[name],
 	}, nil
 }
@@ -47,12 +47,30 @@ func (t7 *T7File) VerifyChecksum() error {
 	}
 	t7.printFunc(fmt.Sprintf("Calculated FB checksum: %X", calculatedFBChecksum))
 
+	// F2/FB live in the footer and are rewritten on every save; the ECU only
+	// refuses to boot on FW, so only that one fails the load.
+	if t7.f2ChecksumDetected {
+		t7.checksumEvent("F2", calculatedF2Checksum, uint32(t7.checksumF2))
+	}
+	t7.checksumEvent("FB", calculatedFBChecksum, uint32(t7.checksumFB))
+	t7.checksumEvent("FW", calculatedFWChecksum, uint32(c.Value))
+
 	if c.Value != int(calculatedFWChecksum) {
-		return errors.New("checksum mismatch")
+		return &ChecksumError{ECU: ECU_T7, Area: "FW", Offset: c.Address, Expected: be32(calculatedFWChecksum), Actual: be32(uint32(c.Value))}
 	}
 	return nil
 }
 
+func (t7 *T7File) checksumEvent(area string, calculated, stored uint32) {
+	t7.event(LoadEvent{
+		Kind:     EventChecksum,
+		Area:     area,
+		Valid:    calculated == stored,
+		Expected: be32(calculated),
+		Actual:   be32(stored),
+	})
+}
+
 func (t7 *T7File) UpdateChecksum() error {
 	calculatedFWChecksum, err := t7.calculateFWChecksum()
 	if err != nil {
@@ -111,7 +129,7 @@ func (t7 *T7File) setChecksumF2(checksum uint32) {
 func (t7 *T7File) getFWChecksum() (T7Checksum, error) {
 	checksumArea := t7.findChecksumArea()
 	if checksumArea < 0 {
-		return T7Checksum{}, errors.New("checksum area not found")
+		return T7Checksum{}, &OffsetError{ECU: ECU_T7, What: "checksum routine", Offset: -1, Length: len(t7.data), Err: ErrChecksumAreaNotFound}
 	}
 
 	t7.printFunc(fmt.Sprintf("Checksum area: %X", checksumArea))
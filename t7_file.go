@@ -68,6 +68,7 @@ type T7File struct {
 	csumArea [16]T7ChecksumArea
 
 	printFunc func(string)
+	eventFunc func(LoadEvent)
 
 	*Collection // the symbol collection
 }
@@ -88,6 +89,14 @@ func WithT7PrintFunc(f func(string)) T7FileOpt {
 	}
 }
 
+// WithT7EventFunc receives the typed counterpart of the printFunc messages.
+func WithT7EventFunc(f func(LoadEvent)) T7FileOpt {
+	return func(t7 *T7File) error {
+		t7.eventFunc = f
+		return nil
+	}
+}
+
 func NewT7File(data []byte, opts ...T7FileOpt) (*T7File, error) {
 	if len(data) != T7Length {
 		return nil, ErrInvalidLength
@@ -112,6 +121,7 @@ func NewT7File(data []byte, opts ...T7FileOpt) (*T7File, error) {
 		printFunc: func(str string) {
 			log.Println(str)
 		},
+		eventFunc: noEvent,
 	}
 
 	for _, opt := range opts {
@@ -124,15 +134,34 @@ func NewT7File(data []byte, opts ...T7FileOpt) (*T7File, error) {
 }
 
 func (t7 *T7File) parse() (*T7File, error) {
+	t7.event(LoadEvent{Kind: EventStageStarted, Stage: StageSymbols})
 	symbols, err := loadT7Symbols(t7.data, func(s string) {
 		t7.printFunc(s)
-	})
+	}, t7.event)
 	if err != nil {
 		return nil, err
 	}
 	t7.Collection = symbols
+	t7.event(LoadEvent{Kind: EventStageFinished, Stage: StageSymbols})
+
+	t7.event(LoadEvent{Kind: EventStageStarted, Stage: StageHeaders})
 	t7.loadHeaders()
-	return t7, t7.VerifyChecksum()
+	t7.event(LoadEvent{Kind: EventStageFinished, Stage: StageHeaders})
+
+	t7.event(LoadEvent{Kind: EventStageStarted, Stage: StageChecksum})
+	if err := t7.VerifyChecksum(); err != nil {
+		return t7, err
+	}
+	t7.event(LoadEvent{Kind: EventStageFinished, Stage: StageChecksum})
+	return t7, nil
+}
+
+func (t7 *T7File) event(e LoadEvent) {
+	if t7.eventFunc == nil {
+		return
+	}
+	e.ECU = ECU_T7
+	t7.eventFunc(e)
 }
 
 func (t7 *T7File) findESPCalibrationPos() int {
@@ -159,7 +188,7 @@ func (t7 *T7File) UpdateSymbol(sym *Symbol) error {
 	addr := sym.Address
 	if sym.Address > 0x7FFFFF {
 		if sym.Address-sym.SramOffset > uint32(len(t7.data)) {
-			return ErrAddressOutOfRange
+			return &SymbolError{ECU: ECU_T7, Name: sym.Name, Address: sym.Address, Err: ErrAddressOutOfRange}
 		}
 		addr = sym.Address - sym.SramOffset
 	}
@@ -212,7 +241,7 @@ func (t7 *T7File) Byte() ([]byte, error) {
 		addr := sym.Address
 		if sym.Address > 0x7FFFFF {
 			if sym.Address-sym.SramOffset > uint32(len(t7.data)) {
-				return nil, ErrAddressOutOfRange
+				return nil, &SymbolError{ECU: ECU_T7, Name: sym.Name, Address: sym.Address, Err: ErrAddressOutOfRange}
 			}
 			addr = sym.Address - sym.SramOffset
 		}
@@ -129,8 +129,18 @@ func (t7 *T7File) loadHeaders() {
 		}
 	}
 	if (t7.chassisIDCounter > 1 || !t7.immocodeDetected || !t7.chassisIDDetected) && t7.autoFixFooter {
+		var reason string
+		switch {
+		case t7.chassisIDCounter > 1:
+			reason = fmt.Sprintf("%d chassis id fields", t7.chassisIDCounter)
+		case !t7.immocodeDetected:
+			reason = "immobilizer id missing"
+		default:
+			reason = "chassis id missing"
+		}
 		t7.clearPiArea()
 		t7.createPiArea()
+		t7.event(LoadEvent{Kind: EventFooterRepair, Message: reason})
 	}
 }
 
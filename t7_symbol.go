@@ -60,7 +60,7 @@ func fixT7SymbolType(s *Symbol) {
 	}
 }
 
-func loadT7Symbols(data []byte, cb func(string)) (*Collection, error) {
+func loadT7Symbols(data []byte, cb func(string), ev func(LoadEvent)) (*Collection, error) {
 	//for _, h := range GetAllT7HeaderFields(data) {
 	//	switch h.ID {
 	//	case 0x91, 0x94, 0x95, 0x97:
@@ -72,18 +72,18 @@ func loadT7Symbols(data []byte, cb func(string)) (*Collection, error) {
 		// return nil, errors.New("non binarypacked not implemented, send your bin to Roffe")
 		// log.Println("Not a binarypacked version")
 		cb("Not a binarypacked symbol table")
-		return nonBinaryPacked(data, cb)
+		return nonBinaryPacked(data, cb, ev)
 
 	} else {
 		// log.Println("Binary packed version")
 		cb("Found binary packed symbol table")
-		return binaryPacked(data, cb)
+		return binaryPacked(data, cb, ev)
 
 	}
 	// return nil, errors.New("not implemented")
 }
 
-func nonBinaryPacked(data []byte, cb func(string)) (*Collection, error) {
+func nonBinaryPacked(data []byte, cb func(string), ev func(LoadEvent)) (*Collection, error) {
 	symbolListOffset, err := getSymbolListOffSet(data) // 0x15FA in 5168646.BIN
 	if err != nil {
 		return nil, err
@@ -112,6 +112,7 @@ outer:
 	}
 
 	cb(fmt.Sprintln("Symbols found:", symbolCount))
+	ev(LoadEvent{Kind: EventNameSource, Source: NamesPlain})
 
 	searchPattern := []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x20, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xF0}
 	searchPattern[12] = byte(symbolInternalPositions[0] >> 8)
@@ -164,6 +165,7 @@ outer:
 				sym.data = data
 			} else {
 				log.Println(err)
+				ev(LoadEvent{Kind: EventWarning, Message: err.Error()})
 			}
 		}
 
@@ -196,11 +198,12 @@ outer:
 	*/
 	//log.Println("Symbols found: ", symb_count)
 	cb(fmt.Sprintf("Loaded %d symbols from binary", symb_count))
+	ev(LoadEvent{Kind: EventSymbolCount, Count: symb_count})
 
 	return symCol, nil
 }
 
-func binaryPacked(data []byte, cb func(string)) (*Collection, error) {
+func binaryPacked(data []byte, cb func(string), ev func(LoadEvent)) (*Collection, error) {
 	compressed, addressTableOffset, symbolNameTableOffset, symbolTableLength, err := GetT7Offsets(data, cb)
 	if err != nil && !errors.Is(err, ErrSymbolTableNotFound) {
 		return nil, err
@@ -237,19 +240,22 @@ func binaryPacked(data []byte, cb func(string)) (*Collection, error) {
 	}
 	// log.Println("Symbols found: ", symb_count)
 	cb(fmt.Sprintf("Loaded %d symbols from binary", symb_count))
+	ev(LoadEvent{Kind: EventSymbolCount, Count: symb_count})
 
 	if compressed {
 		if bytes.HasPrefix(data[symbolNameTableOffset:symbolNameTableOffset+symbolTableLength], []byte{0xFF, 0xFF, 0xFF, 0xFF}) {
-			return nil, errors.New("compressed symbol table is not present")
+			return nil, &OffsetError{ECU: ECU_T7, What: "compressed symbol table", Offset: symbolNameTableOffset, Length: len(data), Err: ErrSymbolTableNotFound}
 		}
 		symbolNames, err := ExpandCompressedSymbolNames(data[symbolNameTableOffset : symbolNameTableOffset+symbolTableLength])
 		if err != nil {
 			return nil, err
 		}
+		ev(LoadEvent{Kind: EventNameSource, Source: compressedNameSource(data[symbolNameTableOffset:])})
 
 		for i := 0; i < len(symbolNames)-1; i++ {
 			if i >= len(symbols) {
 				cb("Missing symbol in name table " + strconv.Itoa(i))
+				ev(LoadEvent{Kind: EventWarning, Message: "missing symbol in name table " + strconv.Itoa(i)})
 				continue
 			}
 			symbols[i].Name = strings.TrimSpace(symbolNames[i])
@@ -269,6 +275,7 @@ func binaryPacked(data []byte, cb func(string)) (*Collection, error) {
 			if errors.Is(err, ErrVersionNotFound) {
 				cb("Could not determine binary version")
 				cb("Load symbols from XML")
+				ev(LoadEvent{Kind: EventWarning, Message: "could not determine binary version"})
 			} else {
 				return nil, fmt.Errorf("could not determine version: %v", err)
 			}
@@ -280,6 +287,7 @@ func binaryPacked(data []byte, cb func(string)) (*Collection, error) {
 		if err != nil {
 			return nil, err
 		}
+		ev(LoadEvent{Kind: EventNameSource, Source: NamesXML, Message: ver})
 		for i, s := range symbols {
 			if value, ok := nameMap[s.Number]; ok {
 				symbols[i].Name = value
@@ -439,7 +447,7 @@ func getSymbolListOffSet(data []byte) (int, error) {
 			}
 		}
 	}
-	return -1, errors.New("Symbol list not found")
+	return -1, &OffsetError{ECU: ECU_T7, What: "symbol list", Offset: -1, Length: len(data), Err: ErrSymbolTableNotFound}
 }
 
 func readMarkerAddressContent(data []byte, marker byte) (length, retval, val int, err error) {
@@ -522,7 +530,7 @@ func GetT7HeaderField(bin []byte, id byte) ([]byte, error) {
 	if found {
 		return answer, nil
 	}
-	return nil, fmt.Errorf("did not find header for id 0x%02x", id)
+	return nil, &OffsetError{ECU: ECU_T7, What: fmt.Sprintf("header field 0x%02X", id), Offset: -1, Length: len(bin), Err: ErrHeaderNotFound}
 }
 
 func GetAllT7HeaderFields(bin []byte) []*T7HeaderField {
@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"crypto/md5"
 	"encoding/binary"
-	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -23,6 +22,7 @@ type T8File struct {
 
 	autoCorrect bool
 	printFunc   func(string)
+	eventFunc   func(LoadEvent)
 }
 
 type T8FileOpt func(*T8File) error
@@ -41,6 +41,14 @@ func WithT8PrintFunc(f func(string)) T8FileOpt {
 	}
 }
 
+// WithT8EventFunc receives the typed counterpart of the printFunc messages.
+func WithT8EventFunc(f func(LoadEvent)) T8FileOpt {
+	return func(t8 *T8File) error {
+		t8.eventFunc = f
+		return nil
+	}
+}
+
 func NewT8File(data []byte, opts ...T8FileOpt) (*T8File, error) {
 	if len(data) != T8Length {
 		return nil, ErrInvalidLength
@@ -54,6 +62,7 @@ func NewT8File(data []byte, opts ...T8FileOpt) (*T8File, error) {
 		printFunc: func(s string) {
 			log.Println(s)
 		},
+		eventFunc: noEvent,
 	}
 	for _, opt := range opts {
 		if err := opt(t8); err != nil {
@@ -65,21 +74,33 @@ func NewT8File(data []byte, opts ...T8FileOpt) (*T8File, error) {
 }
 
 func (t8 *T8File) init() (*T8File, error) {
+	t8.event(LoadEvent{Kind: EventStageStarted, Stage: StageChecksum})
 	if err := t8.VerifyChecksum(); err != nil {
 		return nil, err
 	}
+	t8.event(LoadEvent{Kind: EventStageFinished, Stage: StageChecksum})
 
+	t8.event(LoadEvent{Kind: EventStageStarted, Stage: StageSymbols})
 	col, err := loadT8Symbols(t8.data, func(s string) {
 		t8.printFunc(s)
-	})
+	}, t8.event)
 	if err != nil {
 		return nil, err
 	}
 	t8.Collection = col
+	t8.event(LoadEvent{Kind: EventStageFinished, Stage: StageSymbols})
 
 	return t8, nil
 }
 
+func (t8 *T8File) event(e LoadEvent) {
+	if t8.eventFunc == nil {
+		return
+	}
+	e.ECU = ECU_T8
+	t8.eventFunc(e)
+}
+
 func (t8 *T8File) Bytes() []byte {
 	return t8.data
 }
@@ -167,16 +188,20 @@ func (t8 *T8File) VerifyChecksum() error {
 
 	if !bytes.Equal(crc, calculatedCrc) {
 		t8.printFunc("L1 checksum was invalid, should be updated!")
+		stored := bytes.Clone(crc)
 		if t8.autoCorrect {
 			if err := t8.setL1Checksum(offset, calculatedCrc); err != nil {
 				return err
 			}
 			log.Println("L1 checksum updated successfully")
+			t8.event(LoadEvent{Kind: EventChecksum, Area: "L1", Fixed: true, Expected: calculatedCrc, Actual: stored})
 		} else {
-			return fmt.Errorf("L1 Checksum mismatch: %X != %X", crc, calculatedCrc)
+			t8.event(LoadEvent{Kind: EventChecksum, Area: "L1", Expected: calculatedCrc, Actual: stored})
+			return &ChecksumError{ECU: ECU_T8, Area: "L1", Offset: offset + 2, Expected: calculatedCrc, Actual: stored}
 		}
 	} else {
 		t8.printFunc("L1 checksum is valid")
+		t8.event(LoadEvent{Kind: EventChecksum, Area: "L1", Valid: true, Expected: calculatedCrc, Actual: bytes.Clone(crc)})
 	}
 
 	return t8.CalculateLayer2Checksum(offset)
@@ -184,7 +209,7 @@ func (t8 *T8File) VerifyChecksum() error {
 
 func (t8 *T8File) setL1Checksum(offset int, hash []byte) error {
 	if len(hash) != 16 {
-		return errors.New("invalid hash length")
+		return &OffsetError{ECU: ECU_T8, What: "L1 checksum", Offset: offset + 2, Length: len(hash), Err: ErrDataLength}
 	}
 	copy(t8.data[offset+2:offset+2+16], hash)
 	return nil
@@ -193,7 +218,7 @@ func (t8 *T8File) setL1Checksum(offset int, hash []byte) error {
 func (t8 *T8File) GetChecksumAreaOffset(data []byte) (int, error) {
 	const offset = 0x20140
 	if len(data) < offset+4 {
-		return 0, errors.New("data is too short")
+		return 0, &OffsetError{ECU: ECU_T8, What: "checksum area address", Offset: offset, Length: len(data), Err: ErrOffsetOutOfRange}
 	}
 
 	// Read bytes and convert to int
@@ -208,7 +233,7 @@ func (t8 *T8File) GetChecksumAreaOffset(data []byte) (int, error) {
 
 func (t8 *T8File) GetChecksumInFile(offset int) ([]byte, error) {
 	if len(t8.data) < offset+18 {
-		return nil, errors.New("data is too short")
+		return nil, &OffsetError{ECU: ECU_T8, What: "L1 checksum", Offset: offset + 2, Length: len(t8.data), Err: ErrOffsetOutOfRange}
 	}
 	return t8.data[offset+2 : offset+2+16], nil
 }
@@ -277,14 +302,17 @@ func (t8 *T8File) CalculateLayer2Checksum(offset int) error {
 					if t8.autoCorrect {
 						err := t8.UpdateLayer2(offset, checksum0, index)
 						if err != nil {
-							return errors.New("L2 checksum was invalid, autocorrection failed: " + err.Error())
+							return fmt.Errorf("L2 checksum autocorrection: %w", err)
 						}
 						t8.printFunc("Layer 2 checksum updated successfully")
+						t8.event(LoadEvent{Kind: EventChecksum, Area: "L2", Fixed: true, Expected: be32(checksum0), Actual: be32(sum0)})
 						chkFound = true
 					} else {
-						return errors.New("L2 checksum was invalid, update required")
+						t8.event(LoadEvent{Kind: EventChecksum, Area: "L2", Expected: be32(checksum0), Actual: be32(sum0)})
+						return &ChecksumError{ECU: ECU_T8, Area: "L2", Offset: offset + index + 1, Expected: be32(checksum0), Actual: be32(sum0)}
 					}
 				} else {
+					t8.event(LoadEvent{Kind: EventChecksum, Area: "L2", Valid: true, Expected: be32(checksum0), Actual: be32(sum0)})
 					chkFound = true
 					break
 				}
@@ -293,7 +321,7 @@ func (t8 *T8File) CalculateLayer2Checksum(offset int) error {
 	}
 
 	if !chkFound {
-		return errors.New("L2 checksum could not be calculated [file incompatible]")
+		return &OffsetError{ECU: ECU_T8, What: "L2 checksum", Offset: offset, Length: len(t8.data), Err: ErrChecksumAreaNotFound}
 	}
 	t8.printFunc(fmt.Sprintf("L2 checksum: %08X", sum0))
 	t8.printFunc(fmt.Sprintf("L2 calculated checksum: %08X", checksum0))
@@ -314,5 +342,5 @@ func (t8 *T8File) UpdateLayer2(offsetLayer2 int, checksum0 uint32, index int) er
 		copy(t8.data[copyPosition:copyPosition+4], checksumToFile)
 		return nil // Successfully updated
 	}
-	return errors.New("update failed: index out of range or insufficient data length")
+	return &OffsetError{ECU: ECU_T8, What: "L2 checksum", Offset: copyPosition, Length: len(t8.data), Err: ErrOffsetOutOfRange}
 }
@@ -7,7 +7,7 @@ import (
 	"github.com/roffe/ecusymbol/kmp"
 )
 
-func loadT8Symbols(fileBytes []byte, cb func(string)) (*Collection, error) {
+func loadT8Symbols(fileBytes []byte, cb func(string), ev func(LoadEvent)) (*Collection, error) {
 	//addressTableOffset, err := GetAddrTableOffsetBySymbolTable(fileBytes)
 	//if err != nil {
 	//	return nil, err
@@ -39,6 +39,7 @@ func loadT8Symbols(fileBytes []byte, cb func(string)) (*Collection, error) {
 	if err != nil {
 		return nil, err
 	}
+	ev(LoadEvent{Kind: EventNameSource, Source: compressedNameSource(fileBytes[symbtaboffset:])})
 
 	if err := FindAddressTableOffset(fileBytes); err != nil {
 		return nil, err
@@ -123,6 +124,7 @@ func loadT8Symbols(fileBytes []byte, cb func(string)) (*Collection, error) {
 
 	// log.Println("Symbols found: ", symb_count)
 	cb(fmt.Sprintf("Loaded %d symbols from binary", len(symbols)))
+	ev(LoadEvent{Kind: EventSymbolCount, Count: len(symbols)})
 
 	return syms, nil
 }
@@ -271,7 +273,7 @@ func ReadAddressTable(data []byte, offset int) ([]*Symbol, error) {
 
 func GetAddressFromOffset(data []byte, offset int) (int, error) {
 	if offset < 0 || offset > len(data)-4 {
-		return 0, ErrOffsetOutOfRange
+		return 0, &OffsetError{ECU: ECU_T8, What: "address", Offset: offset, Length: len(data), Err: ErrOffsetOutOfRange}
 	}
 	retval := int(data[offset])<<24 | int(data[offset+1])<<16 | int(data[offset+2])<<8 | int(data[offset+3])
 	return retval, nil
@@ -279,7 +281,7 @@ func GetAddressFromOffset(data []byte, offset int) (int, error) {
 
 func GetLengthFromOffset(data []byte, offset int) (int, error) {
 	if offset < 0 || offset > len(data)-2 {
-		return 0, ErrOffsetOutOfRange
+		return 0, &OffsetError{ECU: ECU_T8, What: "length", Offset: offset, Length: len(data), Err: ErrOffsetOutOfRange}
 	}
 	retval := 0
 	retval += int(data[offset]) << 8
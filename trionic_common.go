@@ -2,7 +2,6 @@ package symbol
 
 import (
 	"bytes"
-	"errors"
 	"fmt"
 	"strings"
 
@@ -19,26 +18,29 @@ const (
 	STRUCT   = 0x20 /* struct flag in type */
 )
 
+// blowfishNameMagic starts a blowfish encrypted name table, anything else is lzhuf.
+var blowfishNameMagic = []byte{0xF1, 0x1A, 0x06, 0x5B, 0xA2, 0x6B, 0xCC, 0x6F}
+
 func ExpandCompressedSymbolNames(in []byte) ([]string, error) {
 	if len(in) < 0x1000 {
-		return nil, errors.New("invalid symbol table size")
+		return nil, &OffsetError{ECU: ECU_UNKNOWN, What: "compressed symbol names", Offset: -1, Length: len(in), Err: ErrInvalidSymbolTableHeader}
 	}
 
-	if bytes.HasPrefix(in, []byte{0xF1, 0x1A, 0x06, 0x5B, 0xA2, 0x6B, 0xCC, 0x6F}) {
+	if bytes.HasPrefix(in, blowfishNameMagic) {
 		return blowfish.DecryptSymbolNames(in)
 	}
 
 	expandedFileSize := int(in[0]) | (int(in[1]) << 8) | (int(in[2]) << 16) | (int(in[3]) << 24)
 
 	if expandedFileSize == -1 {
-		return nil, errors.New("invalid expanded file size")
+		return nil, &OffsetError{ECU: ECU_UNKNOWN, What: "compressed symbol names", Offset: 0, Length: len(in), Err: ErrInvalidSymbolTableHeader}
 	}
 
 	out := make([]byte, expandedFileSize)
 	returnedSize := lzhuf.Decode(in, out)
 
 	if returnedSize != expandedFileSize {
-		return nil, fmt.Errorf("decoded data size missmatch: %d != %d", returnedSize, expandedFileSize)
+		return nil, &OffsetError{ECU: ECU_UNKNOWN, What: "compressed symbol names", Offset: -1, Length: len(in), Err: fmt.Errorf("decoded %d bytes, header says %d: %w", returnedSize, expandedFileSize, ErrDataLength)}
 	}
 
 	return strings.Split(string(out), "\r\n"), nil
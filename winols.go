@@ -0,0 +1,115 @@
+package symbol
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+)
+
+// winOLSHeader are the columns of the map list, one map per line. WinOLS maps
+// them onto its own fields in the CSV import dialog; the column names follow
+// its wording.
+var winOLSHeader = []string{
+	"Name", "Description", "Address", "Columns", "Rows",
+	"Organization", "Signed", "Factor", "Offset", "Unit",
+	"X address", "X organization", "X signed", "X factor", "X offset", "X unit",
+	"Y address", "Y organization", "Y signed", "Y factor", "Y offset", "Y unit",
+}
+
+// winOLSOrganization is the WinOLS data organisation of a symbol. Everything
+// this package loads is big-endian, HiLo in WinOLS terms.
+func winOLSOrganization(s *Symbol) string {
+	switch s.elementSize() {
+	case 1:
+		return "8 bit"
+	case 4:
+		return "32 bit (HiLo)"
+	}
+	return "16 bit (HiLo)"
+}
+
+func winOLSSigned(s *Symbol) string {
+	if s.Type&SIGNED == SIGNED {
+		return "yes"
+	}
+	return "no"
+}
+
+// ExportWinOLS writes every symbol of fw that has its data in the binary as a
+// WinOLS map list: semicolon-separated CSV with the address, dimensions, data
+// organisation and scaling of each map and of the axes GetFileInfo names for it.
+// Addresses are file offsets; T7 symbols that live in SRAM are given at the
+// flash copy Save writes them to, Address - SramOffset.
+func ExportWinOLS(fw FirmwareFile) ([]byte, error) {
+	ecu := ecuOf(fw)
+	size := 0
+	if raw, err := rawImage(fw); err == nil {
+		size = len(raw)
+	}
+	exported := exportable(fw, size)
+	address := func(s *Symbol) (uint32, bool) {
+		if s == nil {
+			return 0, false
+		}
+		if ecu == ECU_T7 && s.Address > 0x7FFFFF {
+			a := s.Address - s.SramOffset
+			if a > 0x7FFFFF || size > 0 && int(a)+int(s.Length) > size {
+				return 0, false
+			}
+			return a, true
+		}
+		return s.Address, exported(s)
+	}
+	count := func(s *Symbol) int { return int(s.Length) / s.elementSize() }
+	// axis fills the six columns of an axis, blank when there is none.
+	axis := func(s *Symbol) []string {
+		a, ok := address(s)
+		if !ok {
+			return make([]string, 6)
+		}
+		return []string{
+			fmt.Sprintf("0x%X", a), winOLSOrganization(s), winOLSSigned(s),
+			strconv.FormatFloat(s.Correctionfactor, 'g', -1, 64),
+			strconv.FormatFloat(s.offset(), 'g', -1, 64),
+			s.Unit,
+		}
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Comma = ';'
+	w.Write(winOLSHeader)
+	for _, s := range fw.Symbols() {
+		addr, ok := address(s)
+		if !ok {
+			continue
+		}
+		info := fileInfo(ecu, fw, s.Name)
+		x, y := fw.GetByName(info.X), fw.GetByName(info.Y)
+		_, hasX := address(x)
+		_, hasY := address(y)
+		cols, rows := max(count(s), 1), 1
+		switch {
+		case hasX && hasY && count(x)*count(y) == cols:
+			cols, rows = count(x), count(y)
+		case hasX && count(x) == cols:
+			y = nil
+		default:
+			x, y = nil, nil
+		}
+		rec := []string{
+			s.Name, info.ZDescription, fmt.Sprintf("0x%X", addr),
+			strconv.Itoa(cols), strconv.Itoa(rows),
+			winOLSOrganization(s), winOLSSigned(s),
+			strconv.FormatFloat(s.Correctionfactor, 'g', -1, 64),
+			strconv.FormatFloat(s.offset(), 'g', -1, 64),
+			s.Unit,
+		}
+		rec = append(rec, axis(x)...)
+		rec = append(rec, axis(y)...)
+		w.Write(rec)
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
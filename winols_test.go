@@ -0,0 +1,47 @@
+package symbol
+
+import (
+	"encoding/csv"
+	"strings"
+	"testing"
+)
+
+func TestExportWinOLS(t *testing.T) {
+	sram := testSymbolAt("AirCompCal.PressMap", 0xF03000, SIGNED|CHAR, 1, 1, 2)
+	sram.SramOffset = 0xEF0000
+	lost := testSymbolAt("ActualIn.n_Engine", 0xF00100, 0, 1, 800)
+	fw := &T7File{Collection: NewCollection(
+		testSymbolAt("BFuelCal.AirXSP", 0x1000, 0, 1, 100, 200, 300),
+		testSymbolAt("BFuelCal.RpmYSP", 0x1010, 0, 10, 100, 200),
+		testSymbolAt("BFuelCal.Map", 0x1020, SIGNED, 0.01, 1, 2, 3, 4, 5, 6),
+		testSymbolAt("IgnProt.fi_Offset", 0x1040, LONG, 0.1, 5),
+		sram, lost,
+	)}
+	out, err := ExportWinOLS(fw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := csv.NewReader(strings.NewReader(string(out)))
+	r.Comma = ';'
+	records, err := r.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := make(map[string][]string)
+	for _, rec := range records[1:] {
+		rows[rec[0]] = rec
+	}
+	if len(records) != 6 || rows["ActualIn.n_Engine"] != nil {
+		t.Fatalf("%d lines:\n%s", len(records), out)
+	}
+	m := rows["BFuelCal.Map"]
+	if m[2] != "0x1020" || m[3] != "3" || m[4] != "2" || m[5] != "16 bit (HiLo)" || m[6] != "yes" || m[7] != "0.01" || m[10] != "0x1000" || m[16] != "0x1010" || m[19] != "10" {
+		t.Fatalf("map %q", m)
+	}
+	if c := rows["IgnProt.fi_Offset"]; c[5] != "32 bit (HiLo)" || c[3] != "1" || c[10] != "" {
+		t.Fatalf("constant %q", c)
+	}
+	if s := rows["AirCompCal.PressMap"]; s[2] != "0x13000" || s[5] != "8 bit" {
+		t.Fatalf("sram %q", s)
+	}
+}
@@ -0,0 +1,249 @@
+package symbol
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+)
+
+// TunerPro XDF definitions. Only the parts a symbol maps onto are modelled:
+// constants and tables, their embedded data, the linear math equation and
+// axis links between tables. mmedtypeflags bit 0 is signed, bit 1 LSB first.
+
+const (
+	xdfSigned   = 0x01
+	xdfLSBFirst = 0x02
+
+	xdfEmbedLinked = 3 // embedinfo type: axis values come from linkobjid
+)
+
+type xdfFormat struct {
+	XMLName   xml.Name      `xml:"XDFFORMAT"`
+	Version   string        `xml:"version,attr"`
+	Header    xdfHeader     `xml:"XDFHEADER"`
+	Constants []xdfConstant `xml:"XDFCONSTANT"`
+	Tables    []xdfTable    `xml:"XDFTABLE"`
+}
+
+type xdfHeader struct {
+	Flags       string      `xml:"flags"`
+	Title       string      `xml:"deftitle"`
+	Description string      `xml:"description,omitempty"`
+	BaseOffset  xdfBase     `xml:"BASEOFFSET"`
+	Defaults    xdfDefaults `xml:"DEFAULTS"`
+	Region      xdfRegion   `xml:"REGION"`
+}
+
+type xdfBase struct {
+	Offset   string `xml:"offset,attr"`
+	Subtract int    `xml:"subtract,attr"`
+}
+
+type xdfDefaults struct {
+	SizeBits  int `xml:"datasizeinbits,attr"`
+	SigDigits int `xml:"sigdigits,attr"`
+	Output    int `xml:"outputtype,attr"`
+	Signed    int `xml:"signed,attr"`
+	LSBFirst  int `xml:"lsbfirst,attr"`
+	Float     int `xml:"float,attr"`
+}
+
+type xdfRegion struct {
+	Type  string `xml:"type,attr"`
+	Start string `xml:"startaddress,attr"`
+	Size  string `xml:"size,attr"`
+	Flags string `xml:"regionflags,attr"`
+	Name  string `xml:"name,attr"`
+}
+
+type xdfEmbedded struct {
+	TypeFlags string `xml:"mmedtypeflags,attr,omitempty"`
+	Address   string `xml:"mmedaddress,attr"`
+	SizeBits  int    `xml:"mmedelementsizebits,attr"`
+	Rows      int    `xml:"mmedrowcount,attr,omitempty"`
+	Cols      int    `xml:"mmedcolcount,attr,omitempty"`
+}
+
+type xdfVar struct {
+	ID string `xml:"id,attr"`
+}
+
+type xdfMath struct {
+	Equation string   `xml:"equation,attr"`
+	Vars     []xdfVar `xml:"VAR"`
+}
+
+type xdfEmbedInfo struct {
+	Type   int    `xml:"type,attr"`
+	LinkID string `xml:"linkobjid,attr,omitempty"`
+}
+
+type xdfAxis struct {
+	ID         string        `xml:"id,attr"`
+	UniqueID   string        `xml:"uniqueid,attr,omitempty"`
+	Embedded   *xdfEmbedded  `xml:"EMBEDDEDDATA"`
+	IndexCount int           `xml:"indexcount,omitempty"`
+	Units      string        `xml:"units,omitempty"`
+	EmbedInfo  *xdfEmbedInfo `xml:"embedinfo"`
+	Math       xdfMath       `xml:"MATH"`
+}
+
+type xdfTable struct {
+	UniqueID    string    `xml:"uniqueid,attr"`
+	Flags       string    `xml:"flags,attr,omitempty"`
+	Title       string    `xml:"title"`
+	Description string    `xml:"description,omitempty"`
+	Axes        []xdfAxis `xml:"XDFAXIS"`
+}
+
+type xdfConstant struct {
+	UniqueID    string      `xml:"uniqueid,attr"`
+	Title       string      `xml:"title"`
+	Description string      `xml:"description,omitempty"`
+	Embedded    xdfEmbedded `xml:"EMBEDDEDDATA"`
+	Units       string      `xml:"units,omitempty"`
+	Math        xdfMath     `xml:"MATH"`
+}
+
+func xdfHex(v int) string {
+	return fmt.Sprintf("0x%X", v)
+}
+
+// xdfEquation is the TunerPro form of raw*factor+offset.
+func xdfEquation(factor, offset float64) xdfMath {
+	eq := "X"
+	if factor != 1 {
+		eq += "*" + strconv.FormatFloat(factor, 'g', -1, 64)
+	}
+	if offset > 0 {
+		eq += "+" + strconv.FormatFloat(offset, 'g', -1, 64)
+	} else if offset < 0 {
+		eq += strconv.FormatFloat(offset, 'g', -1, 64)
+	}
+	return xdfMath{Equation: eq, Vars: []xdfVar{{ID: "X"}}}
+}
+
+func xdfTypeFlags(s *Symbol) string {
+	flags := 0
+	if s.Type&SIGNED == SIGNED {
+		flags |= xdfSigned
+	}
+	return xdfHex(flags)
+}
+
+// ExportXDF writes a TunerPro definition of every flash symbol of fw: a
+// constant for each single value, a table for the rest, with the axes
+// GetFileInfo names linked to their own tables. T7 symbols that only live in
+// SRAM (Address > 0x7FFFFF) are left out, as is anything without an address
+// or past the end of the binary.
+func ExportXDF(fw FirmwareFile, title string) ([]byte, error) {
+	ecu := ecuOf(fw)
+	size := 0
+	if raw, err := rawImage(fw); err == nil {
+		size = len(raw)
+	}
+	doc := xdfFormat{
+		Version: "1.60",
+		Header: xdfHeader{
+			Flags:       "0x1",
+			Title:       title,
+			Description: fw.Version(),
+			BaseOffset:  xdfBase{Offset: "0"},
+			Defaults:    xdfDefaults{SizeBits: 8, SigDigits: 2, Output: 1},
+			Region:      xdfRegion{Type: "0xFFFFFFFF", Start: "0x0", Size: xdfHex(size), Flags: "0x0", Name: "Binary File"},
+		},
+	}
+
+	exported := exportable(fw, size)
+	// Every symbol gets its id up front so a table can link to an axis
+	// whatever order they come in.
+	ids := make(map[string]string)
+	for i, s := range fw.Symbols() {
+		if exported(s) {
+			ids[s.Name] = xdfHex(i + 1)
+		}
+	}
+
+	// What the maps say about their axes describes the axis symbols' own
+	// tables; the axes in a table carry only the units.
+	axisDescription := make(map[string]string)
+	for _, s := range fw.Symbols() {
+		info := fileInfo(ecu, fw, s.Name)
+		if info.X != "" && info.XDescription != "" {
+			axisDescription[info.X] = info.XDescription
+		}
+		if info.Y != "" && info.YDescription != "" {
+			axisDescription[info.Y] = info.YDescription
+		}
+	}
+
+	for _, s := range fw.Symbols() {
+		if !exported(s) {
+			continue
+		}
+		info := fileInfo(ecu, fw, s.Name)
+		description := info.ZDescription
+		if description == "" {
+			description = axisDescription[s.Name]
+		}
+		eq := xdfEquation(s.Correctionfactor, s.offset())
+		width := s.elementSize()
+		if int(s.Length)%width != 0 {
+			// Not a whole number of its elements: export the bytes.
+			width = 1
+		}
+		count := int(s.Length) / width
+		if count <= 1 {
+			doc.Constants = append(doc.Constants, xdfConstant{
+				UniqueID:    ids[s.Name],
+				Title:       s.Name,
+				Description: description,
+				Embedded:    xdfEmbedded{TypeFlags: xdfTypeFlags(s), Address: xdfHex(int(s.Address)), SizeBits: width * 8},
+				Units:       s.Unit,
+				Math:        eq,
+			})
+			continue
+		}
+
+		rows, cols := 1, count
+		x, y := fw.GetByName(info.X), fw.GetByName(info.Y)
+		if exported(x) && exported(y) && x.Length > 0 && y.Length > 0 {
+			cols = int(x.Length) / x.elementSize()
+			rows = int(y.Length) / y.elementSize()
+			if rows*cols != count {
+				rows, cols = 1, count
+			}
+		}
+		axis := func(id string, n int, link *Symbol) xdfAxis {
+			a := xdfAxis{ID: id, IndexCount: n, Math: xdfEquation(1, 0)}
+			if exported(link) {
+				a.Units = link.Unit
+				a.EmbedInfo = &xdfEmbedInfo{Type: xdfEmbedLinked, LinkID: ids[link.Name]}
+			}
+			return a
+		}
+		t := xdfTable{UniqueID: ids[s.Name], Flags: "0x0", Title: s.Name, Description: description}
+		if rows > 1 {
+			t.Axes = append(t.Axes, axis("x", cols, x), axis("y", rows, y))
+		} else {
+			// A curve runs along X, linked to its axis if GetFileInfo has one.
+			t.Axes = append(t.Axes, axis("x", cols, nil), axis("y", 1, nil))
+			if exported(x) && int(x.Length)/x.elementSize() == cols {
+				t.Axes[0] = axis("x", cols, x)
+			}
+		}
+		t.Axes = append(t.Axes, xdfAxis{
+			ID:       "z",
+			Embedded: &xdfEmbedded{TypeFlags: xdfTypeFlags(s), Address: xdfHex(int(s.Address)), SizeBits: width * 8, Rows: rows, Cols: cols},
+			Units:    s.Unit,
+			Math:     eq,
+		})
+		doc.Tables = append(doc.Tables, t)
+	}
+
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), out...), nil
+}
@@ -0,0 +1,162 @@
+package symbol
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const xdfFloat = 0x10000
+
+func LoadXDF(filename string) (*Definition, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return ParseXDF(data)
+}
+
+func ParseXDF(data []byte) (*Definition, error) {
+	var doc xdfFormat
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	base, _ := xdfInt(doc.Header.BaseOffset.Offset)
+	if doc.Header.BaseOffset.Subtract != 0 {
+		base = -base
+	}
+	def := &Definition{Title: doc.Header.Title, Axes: make(AxisInformation)}
+
+	// symbol builds the symbol for embedded data of rows x cols, or says why
+	// it cannot.
+	symbol := func(title string, e *xdfEmbedded, m xdfMath, rows, cols int) (*Symbol, string) {
+		if e == nil || e.Address == "" {
+			return nil, "no address"
+		}
+		addr, err := xdfInt(e.Address)
+		if err != nil {
+			return nil, err.Error()
+		}
+		flags, _ := xdfInt(e.TypeFlags)
+		if flags&(xdfLSBFirst|xdfFloat) != 0 || (e.TypeFlags == "" && doc.Header.Defaults.LSBFirst != 0) {
+			return nil, "little-endian or floating point data"
+		}
+		signed := flags&xdfSigned != 0 || (e.TypeFlags == "" && doc.Header.Defaults.Signed != 0)
+		bits := e.SizeBits
+		if bits == 0 {
+			bits = doc.Header.Defaults.SizeBits
+		}
+		factor, offset, err := linearEquation(m.Equation)
+		if err != nil {
+			return nil, err.Error()
+		}
+		s := &Symbol{
+			Name:             title,
+			Number:           len(def.Symbols),
+			Address:          uint32(addr + base),
+			Correctionfactor: factor,
+		}
+		switch bits {
+		case 8:
+			s.Type = CHAR
+		case 16:
+		case 32:
+			s.Type = LONG
+		default:
+			return nil, fmt.Sprintf("%d bit data", bits)
+		}
+		if signed {
+			s.Type |= SIGNED
+		}
+		s.Length = uint16(max(rows, 1) * max(cols, 1) * bits / 8)
+		s.Offset = offset
+		return s, ""
+	}
+	add := func(s *Symbol, units string) {
+		s.Unit = units
+		def.Symbols = append(def.Symbols, s)
+	}
+
+	for _, c := range doc.Constants {
+		s, why := symbol(c.Title, &c.Embedded, c.Math, 1, 1)
+		if s == nil {
+			def.Skipped = append(def.Skipped, c.Title+": "+why)
+			continue
+		}
+		add(s, c.Units)
+	}
+
+	titles := make(map[string]string)
+	descriptions := make(map[string]string)
+	for _, t := range doc.Tables {
+		titles[t.UniqueID] = t.Title
+		descriptions[t.UniqueID] = t.Description
+	}
+	for _, t := range doc.Tables {
+		var x, y, z *xdfAxis
+		for i := range t.Axes {
+			switch t.Axes[i].ID {
+			case "x":
+				x = &t.Axes[i]
+			case "y":
+				y = &t.Axes[i]
+			case "z":
+				z = &t.Axes[i]
+			}
+		}
+		if z == nil || z.Embedded == nil {
+			def.Skipped = append(def.Skipped, t.Title+": no data")
+			continue
+		}
+		s, why := symbol(t.Title, z.Embedded, z.Math, z.Embedded.Rows, z.Embedded.Cols)
+		if s == nil {
+			def.Skipped = append(def.Skipped, t.Title+": "+why)
+			continue
+		}
+		add(s, z.Units)
+
+		info := Axis{Z: t.Title, ZDescription: t.Description}
+		// An axis is either a link to another table or data of its own,
+		// which becomes a symbol named after the table. A linked axis is
+		// described by its table; one of its own has only its units.
+		axis := func(a *xdfAxis, suffix string, n int) (string, string) {
+			if a == nil {
+				return "", ""
+			}
+			if a.EmbedInfo != nil && a.EmbedInfo.Type == xdfEmbedLinked {
+				if d := descriptions[a.EmbedInfo.LinkID]; d != "" {
+					return titles[a.EmbedInfo.LinkID], d
+				}
+				return titles[a.EmbedInfo.LinkID], a.Units
+			}
+			if a.Embedded == nil || a.Embedded.Address == "" || n < 2 {
+				return "", a.Units
+			}
+			name := t.Title + suffix
+			as, why := symbol(name, a.Embedded, a.Math, 1, n)
+			if as == nil {
+				def.Skipped = append(def.Skipped, name+": "+why)
+				return "", a.Units
+			}
+			add(as, a.Units)
+			return name, a.Units
+		}
+		info.X, info.XDescription = axis(x, ".X", max(z.Embedded.Cols, 1))
+		info.Y, info.YDescription = axis(y, ".Y", max(z.Embedded.Rows, 1))
+		if info.X != "" || info.Y != "" {
+			def.Axes[t.Title] = info
+		}
+	}
+	return def, nil
+}
+
+func xdfInt(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	v, err := strconv.ParseInt(s, 0, 64)
+	return int(v), err
+}
@@ -0,0 +1,175 @@
+package symbol
+
+import (
+	"encoding/xml"
+	"errors"
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestExportXDF(t *testing.T) {
+	fw := &T7File{Collection: NewCollection(
+		testSymbolAt("BFuelCal.AirXSP", 0x1000, 0, 1, 100, 200, 300),
+		testSymbolAt("BFuelCal.RpmYSP", 0x1010, 0, 1, 1000, 2000),
+		testSymbolAt("BFuelCal.Map", 0x1020, SIGNED, 0.01, 1, 2, 3, 4, 5, 6),
+		testSymbolAt("IgnProt.fi_Offset", 0x1040, SIGNED, 0.1, -5),
+		testSymbolAt("ActualIn.n_Engine", 0xF00100, 0, 1, 800),
+	)}
+	fw.GetByName("BFuelCal.AirXSP").Unit = "mg/c"
+	odd := testSymbolAt("Odd", 0x1050, LONG, 1)
+	odd.Length = 6
+	fw.Add(odd)
+	out, err := ExportXDF(fw, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc xdfFormat
+	if err := xml.Unmarshal(out, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Constants) != 1 || len(doc.Tables) != 4 {
+		t.Fatalf("%d constants, %d tables; SRAM symbol not excluded?", len(doc.Constants), len(doc.Tables))
+	}
+	c := doc.Constants[0]
+	if c.Title != "IgnProt.fi_Offset" || c.Embedded.SizeBits != 16 || c.Embedded.TypeFlags != "0x1" || c.Math.Equation != "X*0.1" {
+		t.Fatalf("constant %+v", c)
+	}
+
+	var m xdfTable
+	ids := make(map[string]string)
+	for _, tab := range doc.Tables {
+		ids[tab.UniqueID] = tab.Title
+		if tab.Title == "BFuelCal.Map" {
+			m = tab
+		}
+	}
+	if len(m.Axes) != 3 {
+		t.Fatalf("map axes %+v", m.Axes)
+	}
+	x, y, z := m.Axes[0], m.Axes[1], m.Axes[2]
+	if x.EmbedInfo == nil || ids[x.EmbedInfo.LinkID] != "BFuelCal.AirXSP" || y.EmbedInfo == nil || ids[y.EmbedInfo.LinkID] != "BFuelCal.RpmYSP" {
+		t.Fatalf("axis links x %+v y %+v", x.EmbedInfo, y.EmbedInfo)
+	}
+	if z.Embedded.Rows != 2 || z.Embedded.Cols != 3 || z.Embedded.Address != "0x1020" || z.Math.Equation != "X*0.01" {
+		t.Fatalf("z %+v %+v", z.Embedded, z.Math)
+	}
+	// The axes carry their symbols' units; what the map says about an axis
+	// describes the axis table.
+	if x.Units != "mg/c" || y.Units != "" {
+		t.Fatalf("axis units x %q y %q", x.Units, y.Units)
+	}
+	for _, tab := range doc.Tables {
+		switch tab.Title {
+		case "BFuelCal.RpmYSP":
+			if tab.Description != "rpm" {
+				t.Errorf("%s described as %q", tab.Title, tab.Description)
+			}
+		case "Odd":
+			// Six bytes are no whole number of longs.
+			if e := tab.Axes[2].Embedded; e.SizeBits != 8 || e.Cols != 6 {
+				t.Errorf("Odd exported as %+v", e)
+			}
+		}
+	}
+}
+
+func TestParseXDF(t *testing.T) {
+	fw := &T7File{Collection: NewCollection(
+		testSymbolAt("BFuelCal.AirXSP", 0x1000, 0, 1, 100, 200, 300),
+		testSymbolAt("BFuelCal.RpmYSP", 0x1010, 0, 1, 1000, 2000),
+		testSymbolAt("BFuelCal.Map", 0x1020, SIGNED, 0.01, 1, 2, 3, 4, 5, -6),
+		testSymbolAt("IgnProt.fi_Offset", 0x1040, SIGNED|CHAR, 0.1, -5),
+	)}
+	fw.GetByName("IgnProt.fi_Offset").Offset = 5
+	out, err := ExportXDF(fw, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	def, err := ParseXDF(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(def.Symbols) != 4 || len(def.Skipped) != 0 {
+		t.Fatalf("%d symbols, skipped %v", len(def.Symbols), def.Skipped)
+	}
+	if ax := def.Axes["BFuelCal.Map"]; ax.X != "BFuelCal.AirXSP" || ax.Y != "BFuelCal.RpmYSP" {
+		t.Fatalf("axes %+v", ax)
+	}
+	// The offset stays with the definition, for no other file to pick up.
+	if _, leaked := T5Offsets["IgnProt.fi_Offset"]; leaked {
+		t.Fatal("import wrote T5Offsets")
+	}
+
+	image := make([]byte, 0x2000)
+	for _, s := range fw.Symbols() {
+		copy(image[s.Address:], s.Bytes())
+	}
+	c, err := def.Collection(image)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range fw.Symbols() {
+		got := c.GetByName(want.Name)
+		if got == nil || got.Address != want.Address || got.Length != want.Length || got.Type != want.Type || got.Correctionfactor != want.Correctionfactor {
+			t.Fatalf("%s: got %+v, want %+v", want.Name, got, want)
+		}
+		if !slices.Equal(got.Float64s(), want.Float64s()) {
+			t.Errorf("%s: %v, want %v", want.Name, got.Float64s(), want.Float64s())
+		}
+	}
+	if _, err := def.Collection(image[:0x1030]); !errors.Is(err, ErrAddressOutOfRange) {
+		t.Fatalf("short image: %v", err)
+	}
+}
+
+func TestXDFLinear(t *testing.T) {
+	for _, c := range []struct {
+		eq             string
+		factor, offset float64
+	}{
+		{"", 1, 0},
+		{"X", 1, 0},
+		{"X*0.1+5", 0.1, 5},
+		{"(X-40)*0.75", 0.75, -30},
+		{"X/2 - 1", 0.5, -1},
+		{"-X", -1, 0},
+	} {
+		factor, offset, err := linearEquation(c.eq)
+		if err != nil || math.Abs(factor-c.factor) > 1e-12 || math.Abs(offset-c.offset) > 1e-12 {
+			t.Errorf("%q: %g, %g, %v", c.eq, factor, offset, err)
+		}
+	}
+	for _, eq := range []string{"X*X", "1/X", "X+", "(X"} {
+		if _, _, err := linearEquation(eq); err == nil {
+			t.Errorf("%q: no error", eq)
+		}
+	}
+}
+
+// A T5 binary loaded from a definition keeps its layout to itself.
+func TestT5Definition(t *testing.T) {
+	def := &Definition{
+		Title: "t5",
+		Symbols: []*Symbol{
+			{Name: "Boost.X", Address: 0x100, Length: 4, Correctionfactor: 1},
+			{Name: "Boost", Address: 0x110, Length: 4, Correctionfactor: 0.5, Offset: -10},
+		},
+		Axes: AxisInformation{"Boost": {X: "Boost.X", Z: "Boost"}},
+	}
+	image := make([]byte, LengthT55)
+	copy(image[0x110:], []byte{0, 20, 0, 40})
+	fw, err := NewT5File(image, WithT5Definition(def), WithT5PrintFunc(func(string) {}))
+	if fw == nil {
+		t.Fatal(err)
+	}
+	if ax := GetFileInfo(fw, "Boost"); ax.X != "Boost.X" {
+		t.Fatalf("Boost axes %+v", ax)
+	}
+	if ax := GetInfo(ECU_T5, "Boost"); ax.X != "" {
+		t.Fatalf("definition axes leaked into the T5 tables: %+v", ax)
+	}
+	if got := fw.GetByName("Boost").Float64s(); !slices.Equal(got, []float64{0, 10}) {
+		t.Fatalf("Boost %v", got)
+	}
+}